@@ -0,0 +1,123 @@
+package getqr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Number of samples along each axis of a pixel of an anti-aliased image of a styled QR Code
+const antialiasSamples = 4
+
+// moduleOverlap is the fraction of a pixel's width covered by a module, along either axis
+type moduleOverlap struct {
+	module int
+	weight float64
+}
+
+// Returns the QR Code as an anti-aliased truecolour image
+// Each pixel blends BackgroundColor and ForegroundColor by the fraction of its area covered by dark modules,
+// so modules keep even widths and smooth edges at any size, at the cost of sharpness
+// See the documentation for Image() for the meaning of size. With IntegerScaling set, the image is the same as NRGBAImage()
+// If Style, Fill, ModuleColor or a logo is set, the coverage is approximated by sampling each pixel 4x4 times
+func (q *QRCode) AntialiasedImage(size int) (*image.RGBA, error) {
+	if err := q.validateContrast(); err != nil {
+		return nil, err
+	}
+	// Build QR code
+	q.encode()
+	l := q.ImageLayout(size)
+	img := image.NewRGBA(image.Rect(0, 0, l.Size, l.Size))
+	if q.truecolor() {
+		q.drawSupersampled(l, img)
+	} else {
+		drawCoverage(q.symbol, l, img, q.BackgroundColor, q.ForegroundColor)
+	}
+	return img, nil
+}
+
+// Returns the QR Code as an anti-aliased greyscale image, with the colours converted to grey
+// See the documentation for AntialiasedImage()
+func (q *QRCode) AntialiasedGray(size int) (*image.Gray, error) {
+	img, err := q.AntialiasedImage(size)
+	if err != nil {
+		return nil, err
+	}
+	gray := image.NewGray(img.Rect)
+	draw.Draw(gray, gray.Rect, img, image.Point{}, draw.Src)
+	return gray, nil
+}
+
+// Draws the symbol with layout l into img, blending background and foreground by the exact coverage of each pixel
+func drawCoverage(s *symbol, l ImageLayout, img *image.RGBA, background, foreground color.Color) {
+	// The layout is the same along both axes
+	overlaps := make([][]moduleOverlap, l.Size)
+	for p := range overlaps {
+		m0, m1 := l.moduleSpan(p)
+		for m := int(math.Floor(m0)); float64(m) < m1; m++ {
+			if w := math.Min(m1, float64(m+1)) - math.Max(m0, float64(m)); w > 0 {
+				overlaps[p] = append(overlaps[p], moduleOverlap{m, w / (m1 - m0)})
+			}
+		}
+	}
+	br, bgr, bb, ba := background.RGBA()
+	fr, fg, fb, fa := foreground.RGBA()
+	for y := 0; y < l.Size; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < l.Size; x++ {
+			coverage := 0.0
+			for _, oy := range overlaps[y] {
+				for _, ox := range overlaps[x] {
+					if s.bitmapAt(ox.module, oy.module) {
+						coverage += ox.weight * oy.weight
+					}
+				}
+			}
+			blend := func(b, f uint32) uint8 {
+				return uint8(math.Round((float64(b)*(1-coverage) + float64(f)*coverage) / 0x101))
+			}
+			row[4*x], row[4*x+1], row[4*x+2], row[4*x+3] = blend(br, fr), blend(bgr, fg), blend(bb, fb), blend(ba, fa)
+		}
+	}
+}
+
+// Draws the encoded QR Code with layout l into img, as drawNRGBA() does, averaging 4x4 samples per pixel
+// The samples are drawn a row of pixels at a time, so they take little more memory than a row of the image
+func (q *QRCode) drawSupersampled(l ImageLayout, img *image.RGBA) {
+	const n = antialiasSamples
+	// The same layout with each pixel split into n x n
+	fine := l
+	fine.Size *= n
+	fine.ModuleSize *= n
+	fine.Offset *= n
+	fine.modulesPerPixel /= n
+	drawRows := q.truecolorRows(fine, nrgbaPixel)
+	src := image.NewNRGBA(image.Rect(0, 0, fine.Size, n))
+	for y := 0; y < l.Size; y++ {
+		// The samples of this row of pixels
+		src.Rect = image.Rect(0, y*n, fine.Size, y*n+n)
+		drawRows(src.Pix, src.Stride, y*n, y*n+n)
+		if q.logo != nil {
+			q.logo.draw(src, fine, q.quietZoneSize())
+		}
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < l.Size; x++ {
+			// Sums of the alpha-premultiplied samples
+			var sum [4]int
+			for i := 0; i < n; i++ {
+				p := src.Pix[i*src.Stride+4*x*n:]
+				for j := 0; j < n; j++ {
+					a := int(p[4*j+3])
+					sum[0] += int(p[4*j]) * a
+					sum[1] += int(p[4*j+1]) * a
+					sum[2] += int(p[4*j+2]) * a
+					sum[3] += a * 0xff
+				}
+			}
+			for c := range sum {
+				row[4*x+c] = uint8((sum[c] + n*n*0xff/2) / (n * n * 0xff))
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package getqr
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestAntialiasedImageInteger(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.IntegerScaling = true
+	q.ForegroundColor = color.NRGBA{0x20, 0x40, 0x80, 0xff}
+	for _, style := range []*Style{nil, {}} {
+		q.Style = style
+		img, err := q.AntialiasedImage(150)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := q.NRGBAImage(150)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < want.Rect.Dy(); y++ {
+			for x := 0; x < want.Rect.Dx(); x++ {
+				if !sameColor(img.At(x, y), want.At(x, y)) {
+					t.Fatalf("style %v: pixel (%d, %d): got %v, want %v", style, x, y, img.At(x, y), want.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestAntialiasedGray(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size = 100
+	darkModules := 0
+	for _, row := range q.Bitmap() {
+		for _, v := range row {
+			if v {
+				darkModules++
+			}
+		}
+	}
+	modules := float64(q.bitmapSize())
+	want := float64(darkModules) * size * size / (modules * modules)
+	for _, style := range []*Style{nil, {}} {
+		q.Style = style
+		img, err := q.AntialiasedGray(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The total darkness is the area of the dark modules, in pixels
+		darkness := 0.0
+		partial := 0
+		for _, v := range img.Pix {
+			darkness += float64(0xff-v) / 0xff
+			if v != 0 && v != 0xff {
+				partial++
+			}
+		}
+		tolerance := 0.005
+		if style != nil {
+			// Sampling only approximates the coverage
+			tolerance = 0.03
+		}
+		if darkness < want*(1-tolerance) || darkness > want*(1+tolerance) {
+			t.Errorf("style %v: got a total darkness of %v pixels, want %v", style, darkness, want)
+		}
+		if partial == 0 {
+			t.Errorf("style %v: got no partly covered pixels at a non-integer scale", style)
+		}
+	}
+}
+
+func TestTruecolorRows(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	red := color.NRGBA{0xc0, 0x10, 0x10, 0xff}
+	setups := []func(){
+		func() {},
+		func() { q.Style = &Style{Modules: ModuleCircle} },
+		func() {
+			q.Style = nil
+			q.ModuleColor = func(x, y int, dark bool, role ModuleRole) color.Color {
+				if dark && role == RoleData {
+					return red
+				}
+				return nil
+			}
+		},
+	}
+	for i, setup := range setups {
+		setup()
+		q.encode()
+		l := q.ImageLayout(97)
+		want := q.drawNRGBA(l)
+		// Drawn in bands of 3 rows
+		drawRows := q.truecolorRows(l, nrgbaPixel)
+		stride := 4 * l.Size
+		band := make([]byte, 3*stride)
+		for y0 := 0; y0 < l.Size; y0 += 3 {
+			y1 := y0 + 3
+			if y1 > l.Size {
+				y1 = l.Size
+			}
+			drawRows(band, stride, y0, y1)
+			for y := y0; y < y1; y++ {
+				if string(band[(y-y0)*stride:][:stride]) != string(want.Pix[y*want.Stride:][:stride]) {
+					t.Fatalf("setup %d: row %d drawn in a band differs from the whole image", i, y)
+				}
+			}
+		}
+	}
+}
@@ -53,6 +53,15 @@ func (b *Bitset) Len() int {
 	return b.numBits
 }
 
+// Truncates the Bitset to zero length. The underlying array is kept, so the Bitset can be refilled without reallocation
+func (b *Bitset) Reset() {
+	used := b.bits[:(b.numBits+7)/8]
+	for i := range used {
+		used[i] = 0
+	}
+	b.numBits = 0
+}
+
 // Returns the value of the bit at index
 func (b *Bitset) At(index int) bool {
 	if index >= b.numBits {
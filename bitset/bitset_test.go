@@ -173,6 +173,20 @@ func TestLen(t *testing.T) {
 	}
 }
 
+func TestReset(t *testing.T) {
+	b := New(b1, b1, b0, b1, b1, b1, b1, b1, b1)
+	b.Reset()
+	if b.Len() != 0 {
+		t.Errorf("Len = %d after Reset, want 0", b.Len())
+	}
+	b.AppendBools(b0, b1, b0)
+	b.AppendNumBools(8, b0)
+	expected := []bool{b0, b1, b0, b0, b0, b0, b0, b0, b0, b0, b0}
+	if !equal(b.Bits(), expected) {
+		t.Errorf("Got %v, expected %v", b.Bits(), expected)
+	}
+}
+
 func TestAt(t *testing.T) {
 	test := []bool{b0, b1, b0, b1, b0, b1, b1, b0, b1}
 	bitset := New(test...)
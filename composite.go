@@ -0,0 +1,105 @@
+package getqr
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Standard deviation of the luminance of an area above which it is too busy to scan a QR Code drawn over it without a plate
+const maxBusyness = 0.1
+
+// CompositeOptions configures DrawInto()
+type CompositeOptions struct {
+	Plate     bool // Draw a solid plate of the light colour behind the symbol and its quiet zone. Without it, the image shows through the light modules and quiet zone
+	AutoColor bool // Choose the colours from the luminance of the area in place of ForegroundColor and BackgroundColor, see DrawInto()
+}
+
+// CompositeReport describes a QR Code drawn over an image by DrawInto()
+type CompositeReport struct {
+	ForegroundColor color.Color    // Colour of the dark modules
+	BackgroundColor color.Color    // Colour of the plate, or without one, the average colour of the area drawn over
+	Plate           bool           // A plate was drawn, as requested, or chosen by AutoColor for a dark area
+	Contrast        ContrastReport // Contrast between BackgroundColor and ForegroundColor
+	Busyness        float64        // Standard deviation of the luminance of the area drawn over, from 0 for a flat colour to 0.5
+	Busy            bool           // There is no plate and the area is too busy for the QR Code to scan reliably
+}
+
+// Draws the QR Code, including its quiet zone, into the rectangle r of dst, over the existing image
+// The QR Code is drawn as large as fits in r, centred, as by Image() with its Style, Fill and logo
+// See CompositeOptions for the plate and the choice of colours. The report describes how well the QR Code stands out
+// An error is returned, and nothing drawn, if r is too small for the symbol, or the contrast is below MinContrast
+// AutoColor draws black modules. A plate takes the average colour of the area if that is light enough, and is white otherwise
+// An area too dark for black modules to stand out gets a white plate even if none was requested,
+// rather than light modules on a dark background, which scanners often reject
+func (q *QRCode) DrawInto(dst draw.Image, r image.Rectangle, opts *CompositeOptions) (*CompositeReport, error) {
+	if opts == nil {
+		opts = &CompositeOptions{}
+	}
+	r = r.Intersect(dst.Bounds())
+	size := r.Dx()
+	if r.Dy() < size {
+		size = r.Dy()
+	}
+	modules := q.bitmapSize()
+	if size < modules {
+		return nil, fmt.Errorf("%v is too small for a QR Code %d modules wide", r, modules)
+	}
+	l := newImageLayout(size, modules, q.IntegerScaling)
+	r = image.Rect(0, 0, l.Size, l.Size).Add(r.Min).Add(image.Pt((r.Dx()-l.Size)/2, (r.Dy()-l.Size)/2))
+	mean, busyness := measureArea(dst, r)
+	// Build QR code
+	q.encode()
+	c := *q
+	plate := opts.Plate
+	if opts.AutoColor {
+		c.ForegroundColor, c.BackgroundColor = color.Black, color.White
+		ratio := CheckContrast(mean, color.Black).Ratio
+		if ratio >= recommendedContrastRatio {
+			c.BackgroundColor = mean
+		} else if ratio < minContrastRatio {
+			plate = true
+		}
+	}
+	if !plate {
+		c.BackgroundColor = mean
+	}
+	report := &CompositeReport{
+		ForegroundColor: c.ForegroundColor,
+		BackgroundColor: c.BackgroundColor,
+		Plate:           plate,
+		Contrast:        CheckContrast(c.BackgroundColor, c.ForegroundColor),
+		Busyness:        busyness,
+		Busy:            !plate && busyness > maxBusyness,
+	}
+	if err := c.validateContrast(); err != nil {
+		return nil, err
+	}
+	if !plate {
+		c.BackgroundColor = color.Transparent
+	}
+	draw.Draw(dst, r, c.drawNRGBA(l), image.Point{}, draw.Over)
+	return report, nil
+}
+
+// Returns the average colour of the area r of img, composited over white, and the standard deviation of its luminance
+func measureArea(img image.Image, r image.Rectangle) (color.Color, float64) {
+	var sumR, sumG, sumB, sum, sumSquares float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := compositeOver(img.At(x, y), color.White)
+			l := relativeLuminance(c)
+			sumR += float64(c.R)
+			sumG += float64(c.G)
+			sumB += float64(c.B)
+			sum += l
+			sumSquares += l * l
+		}
+	}
+	n := float64(r.Dx() * r.Dy())
+	mean := color.RGBA{uint8(math.Round(sumR / n)), uint8(math.Round(sumG / n)), uint8(math.Round(sumB / n)), 0xff}
+	variance := sumSquares/n - (sum/n)*(sum/n)
+	return mean, math.Sqrt(math.Max(variance, 0))
+}
@@ -0,0 +1,146 @@
+package getqr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDrawIntoPlate(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const scale = 3
+	template := color.NRGBA{0x80, 0x20, 0x20, 0xff}
+	dst := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(dst, dst.Rect, image.NewUniform(template), image.Point{}, draw.Src)
+	size := q.bitmapSize() * scale
+	// Wider than the symbol, which is centred horizontally
+	r := image.Rect(10, 20, 10+size+40, 20+size)
+	report, err := q.DrawInto(dst, r, &CompositeOptions{Plate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameColor(report.BackgroundColor, q.BackgroundColor) || report.Busy {
+		t.Errorf("got report %+v, want the plate in the background colour and not busy", report)
+	}
+	plain, err := q.NRGBAImage(-scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			want := template
+			if p := image.Pt(x-30, y-20); p.In(plain.Rect) {
+				want = plain.NRGBAAt(p.X, p.Y)
+			}
+			if got := dst.NRGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+
+	if _, err := q.DrawInto(dst, image.Rect(0, 0, 20, 20), nil); err == nil {
+		t.Error("expected an error for a rectangle smaller than the symbol")
+	}
+}
+
+func TestDrawIntoAutoColor(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Checks that dst is the QR Code in black modules on background
+	checkModules := func(dst *image.NRGBA, background color.NRGBA) {
+		t.Helper()
+		l := newImageLayout(100, q.bitmapSize(), false)
+		bitmap := q.Bitmap()
+		for y := 0; y < 100; y++ {
+			for x := 0; x < 100; x++ {
+				want := background
+				if bitmap[l.module(y)][l.module(x)] {
+					want = color.NRGBA{0, 0, 0, 0xff}
+				}
+				if got := dst.NRGBAAt(x, y); got != want {
+					t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, got, want)
+				}
+			}
+		}
+	}
+	white := color.NRGBA{0xff, 0xff, 0xff, 0xff}
+	dark := color.NRGBA{0x10, 0x10, 0x30, 0xff}
+	dst := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(dst, dst.Rect, image.NewUniform(dark), image.Point{}, draw.Src)
+	// Light modules on a dark area would be reversed, so a white plate is drawn
+	report, err := q.DrawInto(dst, dst.Rect, &CompositeOptions{AutoColor: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameColor(report.ForegroundColor, color.Black) || !sameColor(report.BackgroundColor, white) ||
+		!report.Plate || report.Contrast.Reversed || report.Busyness > 1e-6 || report.Busy {
+		t.Errorf("got report %+v, want black modules on a white plate", report)
+	}
+	checkModules(dst, white)
+
+	// A light area is the colour of the plate
+	light := color.NRGBA{0xe0, 0xf0, 0xd0, 0xff}
+	draw.Draw(dst, dst.Rect, image.NewUniform(light), image.Point{}, draw.Src)
+	report, err = q.DrawInto(dst, dst.Rect, &CompositeOptions{AutoColor: true, Plate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameColor(report.ForegroundColor, color.Black) || !sameColor(report.BackgroundColor, light) || !report.Plate {
+		t.Errorf("got report %+v, want black modules on a plate of the light area", report)
+	}
+	checkModules(dst, light)
+
+	draw.Draw(dst, dst.Rect, image.NewUniform(light), image.Point{}, draw.Src)
+	report, err = q.DrawInto(dst, dst.Rect, &CompositeOptions{AutoColor: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameColor(report.ForegroundColor, color.Black) || report.Plate || report.Contrast.Reversed {
+		t.Errorf("got report %+v, want black modules over the light area", report)
+	}
+	if got := dst.NRGBAAt(0, 0); got != light {
+		t.Errorf("quiet zone drawn as %v, want the light area %v to show through", got, light)
+	}
+
+	q.MinContrast = 7
+	q.ForegroundColor = color.NRGBA{0x20, 0x20, 0x20, 0xff}
+	draw.Draw(dst, dst.Rect, image.NewUniform(dark), image.Point{}, draw.Src)
+	if _, err := q.DrawInto(dst, dst.Rect, nil); err == nil {
+		t.Error("expected an error for dark modules on a dark area below MinContrast")
+	}
+}
+
+func TestDrawIntoBusy(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := image.NewGray(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if (x/5+y/5)%2 == 0 {
+				dst.SetGray(x, y, color.Gray{0xff})
+			}
+		}
+	}
+	report, err := q.DrawInto(dst, dst.Rect, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Busy {
+		t.Errorf("got busyness %v, want a checkerboard to be busy", report.Busyness)
+	}
+	report, err = q.DrawInto(dst, dst.Rect, &CompositeOptions{Plate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Busy {
+		t.Error("got a busy report with a plate")
+	}
+}
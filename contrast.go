@@ -0,0 +1,102 @@
+package getqr
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// Contrast ratios below which a colour pair is graded ContrastWarning and ContrastError
+const (
+	recommendedContrastRatio = 4.5
+	minContrastRatio         = 3
+)
+
+// ContrastSeverity grades how reliably a QR Code in a pair of colours can be scanned
+type ContrastSeverity int
+
+const (
+	// Scans reliably
+	ContrastOK ContrastSeverity = iota
+	// Low contrast, or light modules on a dark background, which some scanners cannot read
+	ContrastWarning
+	// Too little contrast for most scanners
+	ContrastError
+)
+
+func (s ContrastSeverity) String() string {
+	switch s {
+	case ContrastOK:
+		return "ok"
+	case ContrastWarning:
+		return "warning"
+	case ContrastError:
+		return "error"
+	}
+	return fmt.Sprintf("ContrastSeverity(%d)", int(s))
+}
+
+// ContrastReport describes how well the modules of a QR Code stand out from its background
+type ContrastReport struct {
+	Ratio    float64 // Luminance contrast ratio, as defined by WCAG 2, from 1 (none) to 21 (black on white)
+	Reversed bool    // The modules are lighter than the background (reversed reflectance)
+	Severity ContrastSeverity
+}
+
+// Returns the contrast between background and foreground colours
+// Transparent colours are assumed to be printed or shown on white
+func CheckContrast(background, foreground color.Color) ContrastReport {
+	bg := compositeOver(background, color.White)
+	fg := compositeOver(foreground, bg)
+	lb, lf := relativeLuminance(bg), relativeLuminance(fg)
+	r := ContrastReport{Reversed: lf > lb}
+	r.Ratio = (math.Max(lb, lf) + 0.05) / (math.Min(lb, lf) + 0.05)
+	switch {
+	case r.Ratio < minContrastRatio:
+		r.Severity = ContrastError
+	case r.Ratio < recommendedContrastRatio || r.Reversed:
+		r.Severity = ContrastWarning
+	}
+	return r
+}
+
+// Returns the contrast between the BackgroundColor and ForegroundColor of the QR Code. See CheckContrast()
+func (q *QRCode) CheckContrast() ContrastReport {
+	return CheckContrast(q.BackgroundColor, q.ForegroundColor)
+}
+
+// Returns an error if the QR Code colours are too similar to tell apart, see validateColorPair(),
+// if MinContrast is set and they have a lower contrast ratio, or if the Fill is invalid or lacks contrast, see validateFill()
+func (q *QRCode) validateContrast() error {
+	if err := validateColorPair(q.BackgroundColor, q.ForegroundColor); err != nil {
+		return err
+	}
+	if q.MinContrast > 0 {
+		if r := q.CheckContrast(); r.Ratio < q.MinContrast {
+			return fmt.Errorf("contrast ratio %.2f of the QR Code colours is below the minimum of %.2f", r.Ratio, q.MinContrast)
+		}
+	}
+	return q.validateFill()
+}
+
+// Returns c composited over the opaque colour background
+func compositeOver(c color.Color, background color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	br, bgr, bb, _ := background.RGBA()
+	blend := func(v, bv uint32) uint8 {
+		return uint8((v + bv*(0xffff-a)/0xffff) >> 8)
+	}
+	return color.RGBA{blend(r, br), blend(g, bgr), blend(b, bb), 0xff}
+}
+
+// Returns the relative luminance of the sRGB colour c, from 0 for black to 1 for white
+func relativeLuminance(c color.RGBA) float64 {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 0xff
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
@@ -0,0 +1,76 @@
+package getqr
+
+import (
+	"bytes"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckContrast(t *testing.T) {
+	tests := []struct {
+		background, foreground color.Color
+		ratio                  float64
+		reversed               bool
+		severity               ContrastSeverity
+	}{
+		{color.White, color.Black, 21, false, ContrastOK},
+		{color.Black, color.White, 21, true, ContrastWarning},
+		{color.White, color.White, 1, false, ContrastError},
+		{color.White, color.RGBA{0x77, 0x77, 0x77, 0xff}, 4.48, false, ContrastWarning},
+		{color.White, color.RGBA{0xcc, 0xcc, 0xcc, 0xff}, 1.61, false, ContrastError},
+		{color.Transparent, color.Black, 21, false, ContrastOK},
+		{color.White, color.NRGBA{0, 0, 0, 0x80}, 4.00, false, ContrastWarning},
+	}
+	for _, test := range tests {
+		r := CheckContrast(test.background, test.foreground)
+		if math.Abs(r.Ratio-test.ratio) > 0.01 || r.Reversed != test.reversed || r.Severity != test.severity {
+			t.Errorf("%v on %v: got %+v, want ratio %.2f reversed %v severity %v",
+				test.foreground, test.background, r, test.ratio, test.reversed, test.severity)
+		}
+	}
+}
+
+func TestMinContrast(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ForegroundColor = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	if _, err := q.PNG(-1); err != nil {
+		t.Errorf("PNG() failed without a minimum contrast: %v", err)
+	}
+	q.MinContrast = 3
+	if _, err := q.PNG(-1); err == nil {
+		t.Error("PNG() accepted light grey on white")
+	}
+	if _, err := q.SVG(nil); err == nil {
+		t.Error("SVG() accepted light grey on white")
+	}
+	q.ForegroundColor = color.Black
+	if _, err := q.PNG(-1); err != nil {
+		t.Errorf("PNG() rejected black on white: %v", err)
+	}
+}
+
+func TestWriteFileMinContrast(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "qr.png")
+	old := []byte("existing file")
+	if err := os.WriteFile(filename, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+	q.ForegroundColor = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	q.MinContrast = 3
+	if err := q.WriteFile(-1, filename); err == nil {
+		t.Fatal("WriteFile() accepted light grey on white")
+	}
+	if b, err := os.ReadFile(filename); err != nil || !bytes.Equal(b, old) {
+		t.Errorf("WriteFile() changed the file on error: %q, %v", b, err)
+	}
+}
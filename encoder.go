@@ -92,41 +92,50 @@ func newDataEncoder(t dataEncoderType) *dataEncoder {
 // Encode data as one or more segments and return the encoded data
 // The returned data does not include the terminator bit sequence
 func (d *dataEncoder) encode(data []byte) (*bitset.Bitset, error) {
+	encoded := bitset.New()
+	if err := d.appendEncoded(encoded, data); err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// Encode data as one or more segments and append the encoded data to encoded
+// The segment lists are reused between calls, so a dataEncoder can encode repeatedly without reallocation
+func (d *dataEncoder) appendEncoded(encoded *bitset.Bitset, data []byte) error {
 	d.data = data
-	d.actual = nil
-	d.optimised = nil
+	d.actual = d.actual[:0]
+	d.optimised = d.optimised[:0]
 	if len(data) == 0 {
-		return nil, errors.New("no data to encode")
+		return errors.New("no data to encode")
 	}
 	// Classify data into unoptimised segments
 	highestRequiredMode := d.classifyDataModes()
 	// Optimise segments.
 	err := d.optimiseDataModes()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	// Check if a single byte encoded segment would be more efficient
 	optimizedLength := 0
 	for _, s := range d.optimised {
 		length, err := d.encodedLength(s.dataMode, len(s.data))
 		if err != nil {
-			return nil, err
+			return err
 		}
 		optimizedLength += length
 	}
 	singleByteSegmentLength, err := d.encodedLength(highestRequiredMode, len(d.data))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if singleByteSegmentLength <= optimizedLength {
-		d.optimised = []segment{{dataMode: highestRequiredMode, data: d.data}}
+		d.optimised = append(d.optimised[:0], segment{dataMode: highestRequiredMode, data: d.data})
 	}
 	// Encode data
-	encoded := bitset.New()
 	for _, s := range d.optimised {
 		d.encodeDataRaw(s.data, s.dataMode, encoded)
 	}
-	return encoded, nil
+	return nil
 }
 
 // Classifies the raw data into unoptimised segments
@@ -201,6 +210,8 @@ func (d *dataEncoder) encodedLength(dataMode dataMode, n int) (int, error) {
 // Segments are merged only if the data modes are compatible and if the merged segment has a shorter encoded length than the individual segments
 // Multiple segments may be merged. For example, a string of alternating alternating alphanumeric/numeric segments ANANANA may be optimized to just A
 func (d *dataEncoder) optimiseDataModes() error {
+	// The segments are consecutive slices of d.data, so merged segments are slices of d.data too
+	offset := 0
 	for i := 0; i < len(d.actual); {
 		mode := d.actual[i].dataMode
 		numChars := len(d.actual[i].data)
@@ -231,11 +242,9 @@ func (d *dataEncoder) optimiseDataModes() error {
 			}
 		}
 		optimised := segment{dataMode: mode,
-			data: make([]byte, 0, numChars)}
-		for k := i; k < j; k++ {
-			optimised.data = append(optimised.data, d.actual[k].data...)
-		}
+			data: d.data[offset : offset+numChars]}
 		d.optimised = append(d.optimised, optimised)
+		offset += numChars
 		i = j
 	}
 	return nil
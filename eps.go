@@ -0,0 +1,107 @@
+package getqr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// EPSOptions configures EPS output. A nil *EPSOptions, like the zero value, draws 1mm modules in RGB
+type EPSOptions struct {
+	ModuleSize float64 // Width and height of a module in millimetres. Zero means 1
+	CMYK       bool    // Use DeviceCMYK colours instead of DeviceRGB. Black becomes pure K
+}
+
+// Returns the QR Code as an Encapsulated PostScript image
+// The quiet zone is included, QuietZone modules wide, as for Image()
+// A fully transparent BackgroundColor leaves the background unpainted. If Style is set, the modules are drawn in the style
+func (q *QRCode) EPS(opts *EPSOptions) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WriteEPS(&b, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code as an Encapsulated PostScript image to out. See the documentation for EPS()
+func (q *QRCode) WriteEPS(out io.Writer, opts *EPSOptions) error {
+	if opts == nil {
+		opts = &EPSOptions{}
+	}
+	moduleSize := opts.ModuleSize
+	if moduleSize < 0 {
+		return fmt.Errorf("invalid EPS module size %v", moduleSize)
+	} else if moduleSize == 0 {
+		moduleSize = 1
+	}
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	if q.logo != nil {
+		return errors.New("logos are not supported in EPS output")
+	}
+	if q.Fill != nil {
+		return errors.New("fills are not supported in EPS output")
+	}
+	// Build QR code
+	q.encode()
+	s := q.symbol
+	scale := moduleSize * pointsPerMM
+	size := float64(s.size) * scale
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "%%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(w, "%%%%BoundingBox: 0 0 %d %d\n", int(math.Ceil(size)), int(math.Ceil(size)))
+	fmt.Fprintf(w, "%%%%HiResBoundingBox: 0 0 %s %s\n", formatNumber(size), formatNumber(size))
+	fmt.Fprintf(w, "%%%%Creator: getqr\n%%%%Title: QR Code\n%%%%LanguageLevel: 2\n%%%%EndComments\n")
+	// r draws a run of n modules starting at (x, y): x y n r
+	fmt.Fprintf(w, "%%%%BeginProlog\n/r { 1 rectfill } bind def\n%%%%EndProlog\n")
+	fmt.Fprintf(w, "gsave\n")
+	if _, _, _, a := q.BackgroundColor.RGBA(); a != 0 {
+		fmt.Fprintf(w, "%s\n0 0 %s %s rectfill\n", epsColor(q.BackgroundColor, opts.CMYK), formatNumber(size), formatNumber(size))
+	}
+	// Draw in module units, with the y axis pointing down from the top left corner
+	if q.ModuleColor != nil {
+		fmt.Fprintf(w, "0 %s translate\n%s %s scale\n", formatNumber(size), formatNumber(scale), formatNumber(-scale))
+		for _, g := range q.moduleColorGroups() {
+			fmt.Fprintf(w, "%s\n", epsColor(g.color, opts.CMYK))
+			for _, r := range g.runs {
+				fmt.Fprintf(w, "%d %d %d r\n", r.x+s.quietZoneSize, r.y+s.quietZoneSize, r.n)
+			}
+		}
+	} else if q.Style != nil {
+		fmt.Fprintf(w, "0 %s translate\n%s %s scale\n", formatNumber(size), formatNumber(scale), formatNumber(-scale))
+		f := pathFormatter{w: w, dx: float64(s.quietZoneSize), dy: float64(s.quietZoneSize), ops: epsPathOperators}
+		for _, g := range q.styledGroups() {
+			fmt.Fprintf(w, "%s\nnewpath\n", epsColor(g.color, opts.CMYK))
+			g.path(&f)
+			fmt.Fprintf(w, "eofill\n")
+		}
+	} else {
+		fmt.Fprintf(w, "%s\n0 %s translate\n%s %s scale\n", epsColor(q.ForegroundColor, opts.CMYK),
+			formatNumber(size), formatNumber(scale), formatNumber(-scale))
+		for y := 0; y < s.symbolSize; y++ {
+			s.forEachRun(y, func(x int, n int) {
+				fmt.Fprintf(w, "%d %d %d r\n", x+s.quietZoneSize, y+s.quietZoneSize, n)
+			})
+		}
+	}
+	fmt.Fprintf(w, "grestore\n%%%%EOF\n")
+	return w.Flush()
+}
+
+// Returns the PostScript operator setting c as the current colour. Alpha is ignored
+func epsColor(c color.Color, cmyk bool) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if cmyk {
+		k := color.CMYKModel.Convert(color.RGBA{n.R, n.G, n.B, 0xff}).(color.CMYK)
+		return fmt.Sprintf("%s %s %s %s setcmykcolor", formatNumber(float64(k.C)/0xff), formatNumber(float64(k.M)/0xff),
+			formatNumber(float64(k.Y)/0xff), formatNumber(float64(k.K)/0xff))
+	}
+	return fmt.Sprintf("%s %s %s setrgbcolor", formatNumber(float64(n.R)/0xff), formatNumber(float64(n.G)/0xff),
+		formatNumber(float64(n.B)/0xff))
+}
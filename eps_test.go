@@ -0,0 +1,51 @@
+package getqr
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEPS(t *testing.T) {
+	q, err := New("getqr", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cmyk := range []bool{false, true} {
+		eps, err := q.EPS(&EPSOptions{ModuleSize: 0.5, CMYK: cmyk})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(eps, []byte("%!PS-Adobe-3.0 EPSF-3.0\n")) {
+			t.Fatal("missing EPS header")
+		}
+		// 29 modules of 0.5mm is 41.1024pt
+		if !bytes.Contains(eps, []byte("%%BoundingBox: 0 0 42 42\n")) {
+			t.Errorf("missing expected BoundingBox in %s", eps)
+		}
+		wantColor := "0 0 0 setrgbcolor"
+		if cmyk {
+			wantColor = "0 0 0 1 setcmykcolor"
+		}
+		if !bytes.Contains(eps, []byte(wantColor)) {
+			t.Errorf("cmyk %v: missing %q", cmyk, wantColor)
+		}
+		area := 0
+		for _, m := range regexp.MustCompile(`(?m)^\d+ \d+ (\d+) r$`).FindAllSubmatch(eps, -1) {
+			n, _ := strconv.Atoi(string(m[1]))
+			area += n
+		}
+		dark := 0
+		for _, row := range q.Bitmap() {
+			for _, v := range row {
+				if v {
+					dark++
+				}
+			}
+		}
+		if area != dark {
+			t.Errorf("cmyk %v: runs cover %d modules, want %d", cmyk, area, dark)
+		}
+	}
+}
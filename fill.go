@@ -0,0 +1,264 @@
+package getqr
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Id of the SVG element defining the fill
+const svgFillID = "fill"
+
+// Fill colours the dark modules of a QR Code in place of ForegroundColor, see QRCode.Fill
+// Positions are fractions of the symbol width, excluding the quiet zone, from the top left corner of the symbol
+// Fill is closed: its implementations are LinearGradient, RadialGradient and ImageFill
+type Fill interface {
+	// Returns the colour of the fill at (x, y)
+	At(x, y float64) color.Color
+	// Returns an error if the fill cannot be drawn
+	validate() error
+	// Calls fn with colours of the fill over a symbol size modules wide and their positions, stopping at its first error
+	sample(size int, fn func(x, y float64, c color.Color) error) error
+	// Writes the SVG element defining the fill, with id svgFillID, for a symbol at (x, y) with width size in user units
+	writeSVG(w *bufio.Writer, x, y, size float64) error
+}
+
+// GradientStop is the colour at a position along a gradient
+type GradientStop struct {
+	Offset float64 // Position along the gradient, from 0 to 1
+	Color  color.Color
+}
+
+// LinearGradient changes colour along the line from (X0, Y0) to (X1, Y1)
+// Beyond its ends, the colours of the first and last stops continue
+type LinearGradient struct {
+	X0, Y0 float64
+	X1, Y1 float64
+	Stops  []GradientStop // In order of increasing Offset
+}
+
+// RadialGradient changes colour from its centre (CX, CY) out to radius R
+// Beyond R, the colour of the last stop continues
+type RadialGradient struct {
+	CX, CY float64
+	R      float64
+	Stops  []GradientStop // In order of increasing Offset
+}
+
+// ImageFill takes the colours from an image, stretched over the symbol
+type ImageFill struct {
+	Image image.Image
+}
+
+func (g *LinearGradient) At(x, y float64) color.Color {
+	dx, dy := g.X1-g.X0, g.Y1-g.Y0
+	return gradientAt(g.Stops, ((x-g.X0)*dx+(y-g.Y0)*dy)/(dx*dx+dy*dy))
+}
+
+func (g *LinearGradient) validate() error {
+	if g.X0 == g.X1 && g.Y0 == g.Y1 {
+		return errors.New("linear gradient has no length")
+	}
+	return validateGradientStops(g.Stops)
+}
+
+func (g *LinearGradient) sample(size int, fn func(x, y float64, c color.Color) error) error {
+	return sampleModules(g, size, fn)
+}
+
+func (g *LinearGradient) writeSVG(w *bufio.Writer, x, y, size float64) error {
+	fmt.Fprintf(w, `<linearGradient id="%s" gradientUnits="userSpaceOnUse" x1="%s" y1="%s" x2="%s" y2="%s">`+"\n", svgFillID,
+		formatNumber(x+g.X0*size), formatNumber(y+g.Y0*size), formatNumber(x+g.X1*size), formatNumber(y+g.Y1*size))
+	writeSVGStops(w, g.Stops)
+	w.WriteString("</linearGradient>\n")
+	return nil
+}
+
+func (g *RadialGradient) At(x, y float64) color.Color {
+	return gradientAt(g.Stops, math.Hypot(x-g.CX, y-g.CY)/g.R)
+}
+
+func (g *RadialGradient) validate() error {
+	if g.R <= 0 {
+		return fmt.Errorf("invalid radial gradient radius %v", g.R)
+	}
+	return validateGradientStops(g.Stops)
+}
+
+func (g *RadialGradient) sample(size int, fn func(x, y float64, c color.Color) error) error {
+	return sampleModules(g, size, fn)
+}
+
+func (g *RadialGradient) writeSVG(w *bufio.Writer, x, y, size float64) error {
+	fmt.Fprintf(w, `<radialGradient id="%s" gradientUnits="userSpaceOnUse" cx="%s" cy="%s" r="%s">`+"\n", svgFillID,
+		formatNumber(x+g.CX*size), formatNumber(y+g.CY*size), formatNumber(g.R*size))
+	writeSVGStops(w, g.Stops)
+	w.WriteString("</radialGradient>\n")
+	return nil
+}
+
+func (f *ImageFill) At(x, y float64) color.Color {
+	if f.Image == nil || f.Image.Bounds().Empty() {
+		return color.Transparent
+	}
+	b := f.Image.Bounds()
+	px := b.Min.X + int(x*float64(b.Dx()))
+	py := b.Min.Y + int(y*float64(b.Dy()))
+	if px >= b.Max.X {
+		px = b.Max.X - 1
+	}
+	if py >= b.Max.Y {
+		py = b.Max.Y - 1
+	}
+	return f.Image.At(max(px, b.Min.X), max(py, b.Min.Y))
+}
+
+func (f *ImageFill) validate() error {
+	if f.Image == nil || f.Image.Bounds().Empty() {
+		return errors.New("image fill has no image")
+	}
+	return nil
+}
+
+// Every pixel of the image is sampled, so that no detail is missed
+func (f *ImageFill) sample(size int, fn func(x, y float64, c color.Color) error) error {
+	b := f.Image.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			err := fn(float64(x-b.Min.X)/float64(b.Dx()), float64(y-b.Min.Y)/float64(b.Dy()), f.Image.At(x, y))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *ImageFill) writeSVG(w *bufio.Writer, x, y, size float64) error {
+	fmt.Fprintf(w, `<pattern id="%s" patternUnits="userSpaceOnUse" x="%s" y="%s" width="%s" height="%s">`+"\n", svgFillID,
+		formatNumber(x), formatNumber(y), formatNumber(size), formatNumber(size))
+	fmt.Fprintf(w, `<image width="%s" height="%s" preserveAspectRatio="none" href="data:image/png;base64,`,
+		formatNumber(size), formatNumber(size))
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if err := encodePNG(enc, f.Image); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	w.WriteString(`"/>` + "\n</pattern>\n")
+	return nil
+}
+
+// Returns the colour at position t along a gradient with stops, interpolating the non-premultiplied colours
+// A gradient without stops is transparent
+func gradientAt(stops []GradientStop, t float64) color.Color {
+	if len(stops) == 0 {
+		return color.Transparent
+	}
+	if t <= stops[0].Offset {
+		return stops[0].Color
+	}
+	for i := 1; i < len(stops); i++ {
+		if t > stops[i].Offset {
+			continue
+		}
+		a, b := stops[i-1], stops[i]
+		if b.Offset == a.Offset {
+			return b.Color
+		}
+		f := (t - a.Offset) / (b.Offset - a.Offset)
+		ca := color.NRGBAModel.Convert(a.Color).(color.NRGBA)
+		cb := color.NRGBAModel.Convert(b.Color).(color.NRGBA)
+		mix := func(u, v uint8) uint8 {
+			return uint8(math.Round(float64(u) + f*(float64(v)-float64(u))))
+		}
+		return color.NRGBA{mix(ca.R, cb.R), mix(ca.G, cb.G), mix(ca.B, cb.B), mix(ca.A, cb.A)}
+	}
+	return stops[len(stops)-1].Color
+}
+
+// Returns an error if stops are missing or out of order
+func validateGradientStops(stops []GradientStop) error {
+	if len(stops) == 0 {
+		return errors.New("gradient has no stops")
+	}
+	for i, s := range stops {
+		if s.Color == nil {
+			return fmt.Errorf("gradient stop %d has no colour", i)
+		}
+		if s.Offset < 0 || s.Offset > 1 || (i > 0 && s.Offset < stops[i-1].Offset) {
+			return fmt.Errorf("gradient stop %d has an invalid offset %v", i, s.Offset)
+		}
+	}
+	return nil
+}
+
+// Samples f at the centre and corners of every module of a symbol size modules wide, see Fill.sample()
+func sampleModules(f Fill, size int, fn func(x, y float64, c color.Color) error) error {
+	for i := 0; i <= 2*size; i++ {
+		for j := 0; j <= 2*size; j++ {
+			x, y := float64(j)/float64(2*size), float64(i)/float64(2*size)
+			if err := fn(x, y, f.At(x, y)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Writes the stop elements of a gradient
+func writeSVGStops(w *bufio.Writer, stops []GradientStop) {
+	for _, s := range stops {
+		n := color.NRGBAModel.Convert(s.Color).(color.NRGBA)
+		fmt.Fprintf(w, `<stop offset="%s" stop-color="#%02x%02x%02x"`, formatNumber(s.Offset), n.R, n.G, n.B)
+		if n.A != 0xff {
+			fmt.Fprintf(w, ` stop-opacity="%s"`, formatNumber(float64(n.A)/0xff))
+		}
+		w.WriteString("/>\n")
+	}
+}
+
+// Returns the Fill, or nil if it cannot be drawn or fails validateFill(),
+// so that drawing methods which cannot return an error use ForegroundColor instead
+func (q *QRCode) drawableFill() Fill {
+	if q.Fill == nil || q.validateFill() != nil {
+		return nil
+	}
+	return q.Fill
+}
+
+// Returns an error if the Fill cannot be drawn, or if a colour sampled from it fails the checks of ForegroundColor:
+// it is too similar to BackgroundColor to tell apart, see validateColorPair(), or MinContrast is set and it has a lower contrast ratio
+// Gradients are sampled at the centre and corners of every module, and images at every pixel
+func (q *QRCode) validateFill() error {
+	if q.Fill == nil {
+		return nil
+	}
+	if err := q.Fill.validate(); err != nil {
+		return err
+	}
+	if q.BackgroundColor == nil {
+		return errors.New("background colour must be set")
+	}
+	checked := make(map[color.NRGBA]bool)
+	return q.Fill.sample(q.version.symbolSize(), func(x, y float64, c color.Color) error {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		if checked[n] {
+			return nil
+		}
+		checked[n] = true
+		if err := validateColorPair(q.BackgroundColor, c); err != nil {
+			return fmt.Errorf("fill colour at (%.3g, %.3g): %v", x, y, err)
+		}
+		if r := CheckContrast(q.BackgroundColor, c); q.MinContrast > 0 && r.Ratio < q.MinContrast {
+			return fmt.Errorf("fill colour %v at (%.3g, %.3g) has a contrast ratio of %.2f with the background, below the minimum of %.2f",
+				c, x, y, r.Ratio, q.MinContrast)
+		}
+		return nil
+	})
+}
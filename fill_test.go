@@ -0,0 +1,147 @@
+package getqr
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestGradientAt(t *testing.T) {
+	stops := []GradientStop{
+		{0.2, color.NRGBA{0, 0, 0, 0xff}},
+		{0.6, color.NRGBA{0x80, 0x40, 0, 0xff}},
+		{1, color.NRGBA{0, 0, 0x80, 0xff}},
+	}
+	tests := []struct {
+		t    float64
+		want color.NRGBA
+	}{
+		{-1, color.NRGBA{0, 0, 0, 0xff}},
+		{0.4, color.NRGBA{0x40, 0x20, 0, 0xff}},
+		{0.6, color.NRGBA{0x80, 0x40, 0, 0xff}},
+		{0.8, color.NRGBA{0x40, 0x20, 0x40, 0xff}},
+		{2, color.NRGBA{0, 0, 0x80, 0xff}},
+	}
+	for _, test := range tests {
+		if got := color.NRGBAModel.Convert(gradientAt(stops, test.t)); got != test.want {
+			t.Errorf("at %v: got %v, want %v", test.t, got, test.want)
+		}
+	}
+	g := &RadialGradient{CX: 0.5, CY: 0.5, R: 0.5, Stops: stops}
+	if got := color.NRGBAModel.Convert(g.At(0.5, 0.2)); got != (color.NRGBA{0x80, 0x40, 0, 0xff}) {
+		t.Errorf("radial gradient: got %v", got)
+	}
+}
+
+func TestLinearGradientFill(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, right := color.NRGBA{0x60, 0, 0, 0xff}, color.NRGBA{0, 0, 0x60, 0xff}
+	q.Fill = &LinearGradient{X1: 1, Stops: []GradientStop{{0, left}, {1, right}}}
+	const scale = 10
+	img, err := q.NRGBAImage(-scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qz := q.QuietZone
+	// The top left finder pattern is near the start of the gradient, and the top right one near its end
+	l := img.NRGBAAt(qz*scale+scale/2, qz*scale+scale/2)
+	r := img.NRGBAAt((qz+q.version.symbolSize())*scale-scale/2, qz*scale+scale/2)
+	if l.R < 0x58 || l.B > 0x08 || r.B < 0x58 || r.R > 0x08 {
+		t.Errorf("got %v on the left and %v on the right, want about %v and %v", l, r, left, right)
+	}
+	if got := img.NRGBAAt(0, 0); got != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("got background %v, want white", got)
+	}
+	svg, err := q.SVG(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(svg), `<linearGradient id="fill"`) || !strings.Contains(string(svg), `fill="url(#fill)"`) {
+		t.Errorf("SVG does not use the gradient:\n%s", svg[:300])
+	}
+}
+
+func TestFillContrastGuard(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As for ForegroundColor, a light grey passes unless MinContrast is set
+	q.Fill = &LinearGradient{X1: 1, Stops: []GradientStop{{0, color.Black}, {1, color.NRGBA{0xdd, 0xdd, 0xdd, 0xff}}}}
+	if _, err := q.PNG(-1); err != nil {
+		t.Errorf("PNG() rejected a light grey gradient without MinContrast: %v", err)
+	}
+	q.MinContrast = 3
+	if _, err := q.PNG(-1); err == nil {
+		t.Error("PNG() ignored MinContrast for the gradient")
+	}
+	q.MinContrast = 0
+	q.Fill = &LinearGradient{X1: 1, Stops: []GradientStop{{0, color.Black}, {1, color.White}}}
+	if _, err := q.PNG(-1); err == nil {
+		t.Error("PNG() accepted a gradient fading into the background")
+	}
+	dark := color.NRGBA{0x20, 0x40, 0x20, 0xff}
+	q.Fill = &ImageFill{Image: testLogo(4, 4, dark)}
+	if _, err := q.PNG(-1); err != nil {
+		t.Errorf("PNG() rejected a dark image fill: %v", err)
+	}
+	q.MinContrast = 15
+	if _, err := q.PNG(-1); err == nil {
+		t.Error("PNG() ignored MinContrast for the image fill")
+	}
+	q.MinContrast = 0
+	// A single pixel the colour of the background, which module sampling would miss
+	img := testLogo(64, 64, dark).(*image.NRGBA)
+	img.Set(4, 4, color.White)
+	q.Fill = &ImageFill{Image: img}
+	if _, err := q.PNG(-1); err == nil {
+		t.Error("PNG() accepted an image fill with a pixel of the background colour")
+	}
+	// Image() cannot fail, so it draws the fill in ForegroundColor
+	q.Fill = nil
+	want := q.Image(-1)
+	q.Fill = &ImageFill{Image: img}
+	got := q.Image(-1)
+	for y := 0; y < got.Bounds().Dy(); y++ {
+		for x := 0; x < got.Bounds().Dx(); x++ {
+			if !sameColor(got.At(x, y), want.At(x, y)) {
+				t.Fatalf("Image() pixel (%d, %d) got %v, want %v in ForegroundColor", x, y, got.At(x, y), want.At(x, y))
+			}
+		}
+	}
+	q.Fill = &LinearGradient{Stops: []GradientStop{{0, color.Black}}}
+	if _, err := q.SVG(nil); err == nil {
+		t.Error("SVG() accepted a gradient with no length")
+	}
+}
+
+func TestInvalidFill(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Style = &Style{Modules: ModuleCircle}
+	want := q.Image(-2)
+	for _, fill := range []Fill{&LinearGradient{X1: 1}, &RadialGradient{R: 1}, &ImageFill{}} {
+		q.Fill = fill
+		// Drawn in ForegroundColor rather than panicking
+		img := q.Image(-2)
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				if !sameColor(img.At(x, y), want.At(x, y)) {
+					t.Fatalf("%T: pixel (%d, %d) got %v, want %v", fill, x, y, img.At(x, y), want.At(x, y))
+				}
+			}
+		}
+		if _, err := q.PNG(-2); err == nil {
+			t.Errorf("%T: expected an error", fill)
+		}
+		if c := fill.At(0.5, 0.5); c != color.Transparent {
+			t.Errorf("%T: got colour %v, want transparent", fill, c)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package getqr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"io"
+	"net/url"
+)
+
+// JPEG quality used when no options are given. High enough that the module edges are not blurred
+const defaultJPEGQuality = 95
+
+// Returns the QR Code as a GIF image. See the documentation for Image() for the meaning of size
+// Styled images with more than 256 colours are reduced to a palette of 216 colours
+func (q *QRCode) GIF(size int) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WriteGIF(&b, size)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code as a GIF image to out. See the documentation for GIF()
+func (q *QRCode) WriteGIF(out io.Writer, size int) error {
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	return gif.Encode(out, palettedImage(q.Image(size)), nil)
+}
+
+// Returns the QR Code as a JPEG image. See the documentation for Image() for the meaning of size
+// A nil opts uses a quality of 95. JPEG has no transparency, so transparent colours are shown on white
+// If BackgroundColor and ForegroundColor are both grey, the image is greyscale, which avoids the blurring of colour edges by chroma subsampling
+func (q *QRCode) JPEG(size int, opts *jpeg.Options) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WriteJPEG(&b, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code as a JPEG image to out. See the documentation for JPEG()
+func (q *QRCode) WriteJPEG(out io.Writer, size int, opts *jpeg.Options) error {
+	if opts == nil {
+		opts = &jpeg.Options{Quality: defaultJPEGQuality}
+	}
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	img := q.Image(size)
+	b := img.Bounds()
+	var dst draw.Image
+	if !q.truecolor() && isGray(q.BackgroundColor) && isGray(q.ForegroundColor) {
+		dst = image.NewGray(b)
+	} else {
+		dst = image.NewRGBA(b)
+	}
+	draw.Draw(dst, b, image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+	return jpeg.Encode(out, dst, opts)
+}
+
+// Reports whether c, shown on white, is a shade of grey
+func isGray(c color.Color) bool {
+	rgb := compositeOver(c, color.White)
+	return rgb.R == rgb.G && rgb.G == rgb.B
+}
+
+// Returns the QR Code as a data URI, for embedding in HTML, CSS or email
+// format is "png", "gif", "jpeg" or "svg". The raster formats are base64 encoded, and SVG is percent-encoded text
+// For the raster formats, see the documentation for Image() for the meaning of size
+// For SVG, a positive size sets the width, and a negative size the width of a module, in user units (pixels)
+func (q *QRCode) DataURI(format string, size int) (string, error) {
+	var data []byte
+	var err error
+	mediaType := "image/" + format
+	switch format {
+	case "png":
+		data, err = q.PNG(size)
+	case "gif":
+		data, err = q.GIF(size)
+	case "jpeg":
+		data, err = q.JPEG(size, nil)
+	case "svg":
+		opts := &SVGOptions{ModuleSize: float64(-size)}
+		if size > 0 {
+			opts.ModuleSize = float64(size) / float64(q.bitmapSize())
+		}
+		data, err = q.SVG(opts)
+		if err != nil {
+			return "", err
+		}
+		return "data:image/svg+xml," + url.PathEscape(string(data)), nil
+	default:
+		return "", fmt.Errorf("unsupported data URI format %q", format)
+	}
+	if err != nil {
+		return "", err
+	}
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// Returns img as a paletted image, keeping its colours if it has at most 256, or else using palette.WebSafe
+func palettedImage(img image.Image) *image.Paletted {
+	if p, ok := img.(*image.Paletted); ok {
+		return p
+	}
+	b := img.Bounds()
+	var colors color.Palette
+	index := make(map[color.Color]bool)
+	for y := b.Min.Y; y < b.Max.Y && len(colors) <= 256; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if c := img.At(x, y); !index[c] {
+				index[c] = true
+				colors = append(colors, c)
+			}
+		}
+	}
+	if len(colors) > 256 {
+		colors = palette.WebSafe
+	}
+	p := image.NewPaletted(b, colors)
+	draw.Draw(p, b, img, b.Min, draw.Src)
+	return p
+}
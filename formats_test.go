@@ -0,0 +1,137 @@
+package getqr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestGIF(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, style := range []*Style{nil, {Modules: ModuleCircle}} {
+		q.Style = style
+		b, err := q.GIF(-3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := gif.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := q.Image(-3)
+		if img.Bounds() != want.Bounds() {
+			t.Fatalf("style %v: got bounds %v, want %v", style, img.Bounds(), want.Bounds())
+		}
+		for y := 0; y < want.Bounds().Dy(); y++ {
+			for x := 0; x < want.Bounds().Dx(); x++ {
+				if !sameColor(img.At(x, y), want.At(x, y)) {
+					t.Fatalf("style %v: pixel (%d, %d): got %v, want %v", style, x, y, img.At(x, y), want.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestJPEG(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const scale = 8
+	for _, fg := range []color.Color{color.Black, color.NRGBA{0, 0, 0xa0, 0xff}} {
+		q.ForegroundColor = fg
+		b, err := q.JPEG(-scale, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, gray := img.(*image.Gray); gray != (fg == color.Black) {
+			t.Errorf("foreground %v: got a %T image", fg, img)
+		}
+		// The centre of each module is close to its colour
+		bitmap := q.Bitmap()
+		want := compositeOver(fg, color.White)
+		for y := range bitmap {
+			for x := range bitmap[y] {
+				got := compositeOver(img.At(x*scale+scale/2, y*scale+scale/2), color.White)
+				c := color.RGBA{0xff, 0xff, 0xff, 0xff}
+				if bitmap[y][x] {
+					c = want
+				}
+				if absDiff(got.R, c.R) > 0x10 || absDiff(got.G, c.G) > 0x10 || absDiff(got.B, c.B) > 0x10 {
+					t.Fatalf("foreground %v: module (%d, %d): got %v, want %v", fg, x, y, got, c)
+				}
+			}
+		}
+	}
+}
+
+func TestDataURI(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoders := map[string]func([]byte) (image.Image, error){
+		"png":  func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) },
+		"gif":  func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) },
+		"jpeg": func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) },
+	}
+	for format, decode := range decoders {
+		uri, err := q.DataURI(format, 128)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix := "data:image/" + format + ";base64,"
+		if !strings.HasPrefix(uri, prefix) {
+			t.Fatalf("got %.40q, want the prefix %q", uri, prefix)
+		}
+		data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, prefix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if img.Bounds().Dx() != 128 {
+			t.Errorf("%s: got a %v image, want 128 pixels wide", format, img.Bounds())
+		}
+	}
+
+	uri, err := q.DataURI("svg", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const prefix = "data:image/svg+xml,"
+	if !strings.HasPrefix(uri, prefix) || strings.ContainsAny(uri, "<>\"# \n") {
+		t.Fatalf("got %.60q, want percent-encoded SVG", uri)
+	}
+	svg, err := url.PathUnescape(strings.TrimPrefix(uri, prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc svgDocument
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Width != "128" {
+		t.Errorf("got width %q, want 128", doc.Width)
+	}
+	if _, err := q.DataURI("tiff", 128); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
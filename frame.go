@@ -0,0 +1,194 @@
+package getqr
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+)
+
+// Default width of the frame around the QR Code, in modules
+const defaultFramePadding = 2
+
+// Largest height of a caption, as a fraction of the symbol width
+const maxCaptionHeight = 0.25
+
+// Frame lays out a QR Code inside a frame, with an optional caption under it, see FramedImage() and FramedSVG()
+// Sizes are in modules. The BackgroundColor of the QR Code must be opaque, so that the frame does not show through its quiet zone
+type Frame struct {
+	Caption      string      // Text drawn in the built-in font, as wide as the symbol. Characters other than printable ASCII are drawn as '?'
+	Padding      float64     // Width of the frame around the QR Code and its quiet zone, and of the space around the caption. Zero means 2
+	CornerRadius float64     // Radius of the rounded corners of the frame. Zero draws square corners
+	Color        color.Color // Colour of the frame. Nil uses ForegroundColor
+	TextColor    color.Color // Colour of the caption. Nil uses BackgroundColor
+}
+
+// frameLayout is a Frame positioned around a QR Code. Positions are in modules from the top left corner of the frame
+type frameLayout struct {
+	width, height float64
+	frame         roundedRect
+	qr            float64 // Distance from the top and left edges of the frame to the QR Code, including its quiet zone
+	bitmap        int     // Width of the QR Code in modules, including its quiet zone
+	glyphs        [][fontWidth]byte
+	textX, textY  float64 // Top left corner of the caption
+	textScale     float64 // Width of a font pixel
+	frameColor    color.Color
+	textColor     color.Color
+}
+
+// Returns the layout of frame around the QR Code with a quiet zone quietZoneSize modules wide
+func (q *QRCode) layoutFrame(frame *Frame, quietZoneSize int) (*frameLayout, error) {
+	if frame == nil {
+		return nil, errors.New("no frame")
+	}
+	padding := frame.Padding
+	if padding == 0 {
+		padding = defaultFramePadding
+	} else if padding < 0 {
+		return nil, fmt.Errorf("invalid frame padding %v", padding)
+	}
+	if frame.CornerRadius < 0 {
+		return nil, fmt.Errorf("invalid frame corner radius %v", frame.CornerRadius)
+	}
+	if _, _, _, a := q.BackgroundColor.RGBA(); a != 0xffff {
+		return nil, errors.New("a framed QR Code needs an opaque background colour for its quiet zone")
+	}
+	symbolSize := float64(q.version.symbolSize())
+	fl := &frameLayout{
+		qr:         padding,
+		bitmap:     q.version.symbolSize() + 2*quietZoneSize,
+		glyphs:     textGlyphs(frame.Caption),
+		frameColor: frame.Color,
+		textColor:  frame.TextColor,
+	}
+	fl.width = float64(fl.bitmap) + 2*padding
+	fl.height = fl.width
+	if n := textWidth(len(fl.glyphs)); n > 0 {
+		fl.textScale = math.Min(symbolSize/float64(n), symbolSize*maxCaptionHeight/fontHeight)
+		fl.textX = (fl.width - float64(n)*fl.textScale) / 2
+		fl.textY = fl.height
+		fl.height += fontHeight*fl.textScale + padding
+	}
+	fl.frame = roundedRect{0, 0, fl.width, fl.height, math.Min(frame.CornerRadius, fl.width/2)}
+	if fl.frameColor == nil {
+		fl.frameColor = q.ForegroundColor
+	}
+	if fl.textColor == nil {
+		fl.textColor = q.BackgroundColor
+	}
+	return fl, nil
+}
+
+// Calls fn for each horizontal run of the caption, giving its top left corner and width
+func (fl *frameLayout) forEachTextRun(fn func(x, y, w float64)) {
+	forEachTextRun(fl.glyphs, func(x, y, n int) {
+		fn(fl.textX+float64(x)*fl.textScale, fl.textY+float64(y)*fl.textScale, float64(n)*fl.textScale)
+	})
+}
+
+// Returns the QR Code inside frame as a non-premultiplied truecolour image. The area outside the rounded corners is transparent
+// A positive size sets the width of the image in pixels. A negative size sets the width of a module, e.g. -5 for 5 pixels per module
+// The QR Code is drawn as by Image(), in its Style and with its logo. With IntegerScaling set, modules are a whole number of pixels
+func (q *QRCode) FramedImage(size int, frame *Frame) (*image.NRGBA, error) {
+	if err := q.validateContrast(); err != nil {
+		return nil, err
+	}
+	fl, err := q.layoutFrame(frame, q.quietZoneSize())
+	if err != nil {
+		return nil, err
+	}
+	// Build QR code
+	q.encode()
+	var scale float64
+	if size < 0 {
+		scale = float64(-size)
+	} else {
+		scale = math.Max(float64(size)/fl.width, 1)
+	}
+	if q.IntegerScaling {
+		scale = math.Max(math.Floor(scale), 1)
+	}
+	// Returns the pixel position of the position m in modules
+	pixel := func(m float64) int {
+		return int(math.Round(m * scale))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, pixel(fl.width), pixel(fl.height)))
+	frameColor := nrgbaPixel(fl.frameColor)
+	for y := 0; y < img.Rect.Dy(); y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if fl.frame.contains((float64(x)+0.5)/scale, (float64(y)+0.5)/scale) {
+				copy(row[4*x:], frameColor[:])
+			}
+		}
+	}
+	qr := image.Pt(pixel(fl.qr), pixel(fl.qr))
+	symbol := q.drawNRGBA(newImageLayout(pixel(fl.qr+float64(fl.bitmap))-qr.X, fl.bitmap, q.IntegerScaling))
+	draw.Draw(img, symbol.Rect.Add(qr), symbol, image.Point{}, draw.Over)
+	text := image.NewUniform(fl.textColor)
+	fl.forEachTextRun(func(x, y, w float64) {
+		r := image.Rect(pixel(x), pixel(y), pixel(x+w), pixel(y+fl.textScale))
+		draw.Draw(img, r, text, image.Point{}, draw.Over)
+	})
+	return img, nil
+}
+
+// Returns the QR Code inside frame as an SVG image. The caption is drawn as a path, so no font is needed to display it
+// opts are used as by SVG(), with sizes in modules of the frame
+func (q *QRCode) FramedSVG(frame *Frame, opts *SVGOptions) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WriteFramedSVG(&b, frame, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code inside frame as an SVG image to out. See the documentation for FramedSVG()
+func (q *QRCode) WriteFramedSVG(out io.Writer, frame *Frame, opts *SVGOptions) error {
+	if opts == nil {
+		opts = &SVGOptions{}
+	}
+	moduleSize, err := svgModuleSize(opts)
+	if err != nil {
+		return err
+	}
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	quietZoneSize := q.quietZoneSize()
+	fl, err := q.layoutFrame(frame, quietZoneSize)
+	if err != nil {
+		return err
+	}
+	// Build QR code
+	q.encode()
+	w := bufio.NewWriter(out)
+	writeSVGStart(w, fl.width, fl.height, moduleSize, opts)
+	fmt.Fprintf(w, `<rect width="%s" height="%s"`, formatNumber(fl.width), formatNumber(fl.height))
+	if r := fl.frame.r; r > 0 {
+		fmt.Fprintf(w, ` rx="%s"`, formatNumber(r))
+	}
+	fmt.Fprintf(w, "%s/>\n", svgFill(fl.frameColor))
+	fmt.Fprintf(w, `<g transform="translate(%s %s)">`+"\n", formatNumber(fl.qr), formatNumber(fl.qr))
+	fmt.Fprintf(w, `<rect width="%d" height="%d"%s/>`+"\n", fl.bitmap, fl.bitmap, svgFill(q.BackgroundColor))
+	if err := q.writeSVGModules(w, quietZoneSize, opts.Outline); err != nil {
+		return err
+	}
+	w.WriteString("</g>\n")
+	if len(fl.glyphs) > 0 {
+		fmt.Fprintf(w, `<path%s d="`, svgFill(fl.textColor))
+		h := formatNumber(fl.textScale)
+		fl.forEachTextRun(func(x, y, width float64) {
+			fmt.Fprintf(w, "M%s %sh%sv%sh-%sz", formatNumber(x), formatNumber(y), formatNumber(width), h, formatNumber(width))
+		})
+		w.WriteString(`"/>` + "\n")
+	}
+	w.WriteString("</svg>\n")
+	return w.Flush()
+}
@@ -0,0 +1,174 @@
+package getqr
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"math/bits"
+	"testing"
+)
+
+func TestTextGlyphs(t *testing.T) {
+	if len(font5x7) != '~'-' '+1 {
+		t.Fatalf("font has %d glyphs, want %d", len(font5x7), '~'-' '+1)
+	}
+	for i, g := range font5x7 {
+		for _, column := range g {
+			if column>>fontHeight != 0 {
+				t.Errorf("glyph %q is taller than %d pixels", rune(' '+i), fontHeight)
+			}
+		}
+	}
+	glyphs := textGlyphs("Ab\x01é")
+	want := [][fontWidth]byte{font5x7['A'-' '], font5x7['b'-' '], font5x7['?'-' '], font5x7['?'-' ']}
+	if len(glyphs) != len(want) {
+		t.Fatalf("got %d glyphs, want %d", len(glyphs), len(want))
+	}
+	for i := range want {
+		if glyphs[i] != want[i] {
+			t.Errorf("glyph %d: got %v, want %v", i, glyphs[i], want[i])
+		}
+	}
+	if w := textWidth(3); w != 17 {
+		t.Errorf("textWidth(3) = %d, want 17", w)
+	}
+}
+
+func TestForEachTextRun(t *testing.T) {
+	glyphs := textGlyphs("Hello, World")
+	want := 0
+	for _, g := range glyphs {
+		for _, column := range g {
+			want += bits.OnesCount8(column)
+		}
+	}
+	width := textWidth(len(glyphs))
+	pixels := make(map[image.Point]bool)
+	forEachTextRun(glyphs, func(x, y, n int) {
+		if x < 0 || x+n > width || y < 0 || y >= fontHeight {
+			t.Errorf("run of %d at (%d, %d) is outside the text", n, x, y)
+		}
+		for i := 0; i < n; i++ {
+			column := (x + i) % (fontWidth + fontSpacing)
+			if column == fontWidth || glyphs[(x+i)/(fontWidth+fontSpacing)][column]&(1<<uint(y)) == 0 {
+				t.Errorf("run of %d at (%d, %d) covers the unset pixel (%d, %d)", n, x, y, x+i, y)
+			}
+			pixels[image.Pt(x+i, y)] = true
+		}
+	})
+	if len(pixels) != want {
+		t.Errorf("runs cover %d pixels, want %d", len(pixels), want)
+	}
+}
+
+func TestFramedImage(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frameColor := color.NRGBA{0x20, 0x40, 0x80, 0xff}
+	textColor := color.NRGBA{0xff, 0xff, 0, 0xff}
+	const scale = 4
+	frame := &Frame{Caption: "SCAN ME", Padding: 3, CornerRadius: 4, Color: frameColor, TextColor: textColor}
+	img, err := q.FramedImage(-scale, frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := q.bitmapSize()
+	if img.Rect.Dx() != (bitmap+6)*scale || img.Rect.Dy() <= img.Rect.Dx() {
+		t.Fatalf("got a %v image, want %d pixels wide with a caption below", img.Rect, (bitmap+6)*scale)
+	}
+	if c := img.NRGBAAt(0, 0); c.A != 0 {
+		t.Errorf("got %v outside the rounded corner, want transparent", c)
+	}
+	if c := img.NRGBAAt(scale, img.Rect.Dy()/2); c != frameColor {
+		t.Errorf("got %v on the frame, want %v", c, frameColor)
+	}
+	plain, err := q.NRGBAImage(-scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < plain.Rect.Dy(); y++ {
+		for x := 0; x < plain.Rect.Dx(); x++ {
+			if got, want := img.NRGBAAt(x+3*scale, y+3*scale), plain.NRGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d) of the QR Code: got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	// The caption is as wide as the symbol, so its pixels span the symbol's columns
+	minX, maxX := img.Rect.Dx(), 0
+	for y := (bitmap + 6) * scale; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			if img.NRGBAAt(x, y) != textColor {
+				continue
+			}
+			if x < minX {
+				minX = x
+			}
+			maxX = max(maxX, x)
+		}
+	}
+	symbolLeft, symbolRight := (3+q.QuietZone)*scale, (3+bitmap-q.QuietZone)*scale-1
+	if minX < symbolLeft-scale || minX > symbolLeft+scale || maxX < symbolRight-scale || maxX > symbolRight+scale {
+		t.Errorf("caption spans pixels %d to %d, want about %d to %d", minX, maxX, symbolLeft, symbolRight)
+	}
+
+	noCaption, err := q.FramedImage(200, &Frame{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noCaption.Rect.Dx() != 200 || noCaption.Rect.Dy() != 200 {
+		t.Errorf("got a %v image without a caption, want 200x200", noCaption.Rect)
+	}
+	if c := noCaption.At(0, 0); !sameColor(c, q.ForegroundColor) {
+		t.Errorf("got frame colour %v, want the foreground colour", c)
+	}
+	for _, f := range []*Frame{nil, {Padding: -1}, {CornerRadius: -1}} {
+		if _, err := q.FramedImage(100, f); err == nil {
+			t.Errorf("frame %+v: expected an error", f)
+		}
+	}
+	q.BackgroundColor = color.NRGBA{0xff, 0xff, 0xff, 0x80}
+	if _, err := q.FramedImage(100, &Frame{}); err == nil {
+		t.Error("FramedImage() accepted a translucent background")
+	}
+	if _, err := q.FramedSVG(&Frame{}, nil); err == nil {
+		t.Error("FramedSVG() accepted a translucent background")
+	}
+}
+
+func TestFramedSVG(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := q.FramedSVG(&Frame{Caption: "Hi", CornerRadius: 2}, &SVGOptions{ModuleSize: 2, Unit: "mm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		svgDocument
+		Frame struct {
+			RX string `xml:"rx,attr"`
+		} `xml:"rect"`
+		Group struct {
+			Transform string `xml:"transform,attr"`
+		} `xml:"g"`
+	}
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	width := q.bitmapSize() + 4
+	if want := formatNumber(float64(2*width)) + "mm"; doc.Width != want {
+		t.Errorf("got width %q, want %q", doc.Width, want)
+	}
+	if doc.Frame.RX != "2" {
+		t.Errorf("got frame corner radius %q, want 2", doc.Frame.RX)
+	}
+	if doc.Group.Transform != "translate(2 2)" {
+		t.Errorf("got QR Code transform %q, want translate(2 2)", doc.Group.Transform)
+	}
+	if len(doc.Paths) != 1 || doc.Paths[0].Fill != "#ffffff" || doc.Paths[0].D == "" {
+		t.Errorf("got caption paths %+v, want one white path", doc.Paths)
+	}
+}
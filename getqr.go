@@ -6,53 +6,59 @@ import (
 	"fmt"
 	"image"
 	"image/color"
-	"image/png"
 	"io"
-	"io/ioutil"
 	"log"
 	"os"
+	"runtime"
+	"sync"
 
 	bitset "github.com/pchchv/getqr/bitset"
 	reedsolomon "github.com/pchchv/getqr/reedsolomon"
 )
 
+// Pad codewords 0b11101100 and 0b00010001
+var padCodewords = [2]byte{0xec, 0x11}
+
+// Number of data mask patterns defined for QR Codes
+const numMasks = 8
+
+// Smallest version for which mask candidates are evaluated concurrently
+// Below this the goroutine overhead outweighs the cost of building and scoring a candidate
+const minParallelMaskVersion = 10
+
 type QRCode struct {
 	Content         string        // Original content encoded
 	Level           RecoveryLevel // QR Code type
 	VersionNumber   int
 	BackgroundColor color.Color // User settable drawing options
 	ForegroundColor color.Color
-	DisableBorder   bool // Disable the QR Code border
-	Border          bool // QR Code border. True — borders are enabled
+	QuietZone       int             // Width of the quiet zone (border) around the symbol in modules. New() sets the standard width of 4, 0 draws no quiet zone
+	DisableBorder   bool            // Deprecated: set QuietZone to 0 instead. Draws no quiet zone, whatever QuietZone is set to
+	Border          bool            // Deprecated: set QuietZone instead. Has no effect
+	IntegerScaling  bool            // Draw images with a whole number of pixels per module, centred in the image. See ImageLayout()
+	Style           *Style          // Shapes and colours of the modules for Image(), PNG() and the vector formats. Nil draws plain squares
+	Fill            Fill            // Gradient or image colouring the dark modules in place of ForegroundColor, for Image(), PNG() and SVG(). Its colours are checked as ForegroundColor is. Image() draws a Fill failing the checks as ForegroundColor
+	ModuleColor     ModuleColorFunc // Colours each module as a square, for Image(), PNG() and the vector formats. Overrides Style and Fill
+	MinContrast     float64         // Minimum contrast ratio of the colours, and of the Fill colours, see CheckContrast(). Rendering methods returning an error fail below it. Zero disables the check
 	encoder         *dataEncoder
 	version         qrCodeVersion
 	data            *bitset.Bitset
 	symbol          *symbol
 	mask            int
+	logo            *logoPlacement
 }
 
 // Constructs a QR Code. An error occurs if the content is too long
 func New(content string, level RecoveryLevel) (*QRCode, error) {
-	var err error
-	var encoder *dataEncoder
-	var encoded *bitset.Bitset
-	var chosenVersion *qrCodeVersion
-	encoders := []dataEncoderType{dataEncoderType1To9, dataEncoderType10To26, dataEncoderType27To40}
-	for _, t := range encoders {
-		encoder = newDataEncoder(t)
-		encoded, err = encoder.encode([]byte(content))
-		if err != nil {
-			continue
-		}
-		chosenVersion = chooseQRCodeVersion(level, encoder, encoded.Len())
-		if chosenVersion != nil {
-			break
-		}
+	encoders := [...]*dataEncoder{
+		newDataEncoder(dataEncoderType1To9),
+		newDataEncoder(dataEncoderType10To26),
+		newDataEncoder(dataEncoderType27To40),
 	}
+	encoded := bitset.New()
+	encoder, chosenVersion, err := chooseEncoding([]byte(content), level, encoders[:], encoded)
 	if err != nil {
 		return nil, err
-	} else if chosenVersion == nil {
-		return nil, errors.New("content too long to encode")
 	}
 	q := &QRCode{
 		Content:         content,
@@ -60,6 +66,7 @@ func New(content string, level RecoveryLevel) (*QRCode, error) {
 		VersionNumber:   chosenVersion.version,
 		ForegroundColor: color.Black,
 		BackgroundColor: color.White,
+		QuietZone:       chosenVersion.quietZoneSize(),
 		encoder:         encoder,
 		data:            encoded,
 		version:         *chosenVersion,
@@ -67,6 +74,32 @@ func New(content string, level RecoveryLevel) (*QRCode, error) {
 	return q, nil
 }
 
+// Encodes content with each of encoders in turn, until the encoded data fits in a QR Code version at level
+// The encoded data is stored in encoded, replacing its contents. The encoder used and the chosen version are returned
+func chooseEncoding(content []byte, level RecoveryLevel, encoders []*dataEncoder,
+	encoded *bitset.Bitset) (*dataEncoder, *qrCodeVersion, error) {
+	var err error
+	var encoder *dataEncoder
+	var chosenVersion *qrCodeVersion
+	for _, encoder = range encoders {
+		encoded.Reset()
+		err = encoder.appendEncoded(encoded, content)
+		if err != nil {
+			continue
+		}
+		chosenVersion = chooseQRCodeVersion(level, encoder, encoded.Len())
+		if chosenVersion != nil {
+			break
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	} else if chosenVersion == nil {
+		return nil, nil, errors.New("content too long to encode")
+	}
+	return encoder, chosenVersion, nil
+}
+
 // Constructs a QR code of a specific version. An error occurs in case of invalid version
 func NewWithForcedVersion(content string, version int, level RecoveryLevel) (*QRCode, error) {
 	var encoder *dataEncoder
@@ -101,6 +134,7 @@ func NewWithForcedVersion(content string, version int, level RecoveryLevel) (*QR
 		VersionNumber:   chosenVersion.version,
 		ForegroundColor: color.Black,
 		BackgroundColor: color.White,
+		QuietZone:       chosenVersion.quietZoneSize(),
 		encoder:         encoder,
 		data:            encoded,
 		version:         *chosenVersion,
@@ -122,15 +156,10 @@ func (q *QRCode) addPadding() {
 	}
 	// Pad to the nearest codeword boundary
 	q.data.AppendNumBools(q.version.numBitsToPadToCodeword(q.data.Len()), false)
-	// Pad codewords 0b11101100 and 0b00010001
-	padding := [2]*bitset.Bitset{
-		bitset.New(true, true, true, false, true, true, false, false),
-		bitset.New(false, false, false, true, false, false, false, true),
-	}
 	// Insert pad codewords alternately
 	i := 0
 	for numDataBits-q.data.Len() >= 8 {
-		q.data.Append(padding[i])
+		q.data.AppendByte(padCodewords[i], 8)
 		i = 1 - i // Alternate between 0 and 1
 	}
 	if q.data.Len() != numDataBits {
@@ -142,94 +171,201 @@ func (q *QRCode) addPadding() {
 // applies error correction to each block, then interleaves the blocks together
 // The QR Code's final data sequence is returned
 func (q *QRCode) encodeBlocks() *bitset.Bitset {
-	// Split into blocks
-	type dataBlock struct {
-		data          *bitset.Bitset
-		ecStartOffset int
+	result := bitset.New()
+	appendBlocks(result, q.version, q.data, nil)
+	return result
+}
+
+// Splits data into blocks as specified by version, applies error correction to each block,
+// then appends the interleaved blocks and the remainder bits to result
+// codewords is scratch space for the blocks. It is returned, grown if necessary, so it can be reused
+func appendBlocks(result *bitset.Bitset, version qrCodeVersion, data *bitset.Bitset, codewords []byte) []byte {
+	numCodewords := 0
+	maxDataCodewords := 0
+	maxErrorCodewords := 0
+	for _, b := range version.block {
+		numCodewords += b.numBlocks * b.numCodewords
+		maxDataCodewords = max(maxDataCodewords, b.numDataCodewords)
+		maxErrorCodewords = max(maxErrorCodewords, b.numCodewords-b.numDataCodewords)
+	}
+	if cap(codewords) < numCodewords {
+		codewords = make([]byte, numCodewords)
 	}
-	block := make([]dataBlock, q.version.numBlocks())
+	codewords = codewords[:numCodewords]
+	// Split into blocks, stored one after the other, and apply error correction to each block
 	start := 0
-	end := 0
-	blockID := 0
-	for _, b := range q.version.block {
+	dataBit := 0
+	for _, b := range version.block {
 		for j := 0; j < b.numBlocks; j++ {
-			start = end
-			end = start + b.numDataCodewords*8
-			// Apply error correction to each block.
-			numErrorCodewords := b.numCodewords - b.numDataCodewords
-			block[blockID].data = reedsolomon.Encode(q.data.Substr(start, end), numErrorCodewords)
-			block[blockID].ecStartOffset = end - start
-			blockID++
-		}
-	}
-	// Interleave the blocks
-	result := bitset.New()
-	// Combine data blocks
-	working := true
-	for i := 0; working; i += 8 {
-		working = false
-		for j, b := range block {
-			if i >= block[j].ecStartOffset {
-				continue
+			block := codewords[start : start+b.numCodewords]
+			for i := 0; i < b.numDataCodewords; i++ {
+				block[i] = data.ByteAt(dataBit)
+				dataBit += 8
 			}
-			result.Append(b.data.Substr(i, i+8))
-			working = true
+			reedsolomon.EncodeBytes(block[:b.numDataCodewords], block[b.numDataCodewords:])
+			start += b.numCodewords
 		}
 	}
-	// Combine error correction blocks
-	working = true
-	for i := 0; working; i += 8 {
-		working = false
-		for j, b := range block {
-			offset := i + block[j].ecStartOffset
-			if offset >= block[j].data.Len() {
-				continue
+	// Interleave the blocks: first the data codewords, then the error correction codewords
+	for i := 0; i < maxDataCodewords+maxErrorCodewords; i++ {
+		start = 0
+		for _, b := range version.block {
+			for j := 0; j < b.numBlocks; j++ {
+				offset := i
+				if i >= maxDataCodewords {
+					offset = b.numDataCodewords + i - maxDataCodewords
+				}
+				if (i < maxDataCodewords && offset < b.numDataCodewords) ||
+					(i >= maxDataCodewords && offset < b.numCodewords) {
+					result.AppendByte(codewords[start+offset], 8)
+				}
+				start += b.numCodewords
 			}
-			result.Append(b.data.Substr(offset, offset+8))
-			working = true
 		}
 	}
 	// Append remainder bits.
-	result.AppendNumBools(q.version.numRemainderBits, false)
-	return result
+	result.AppendNumBools(version.numRemainderBits, false)
+	return codewords
 }
 
 // Returns the QR Code as a 2D array of 1-bit pixels bitmap[y][x] is true if the pixel at (x, y) is set
-// The bitmap includes the required "quiet zone" around the QR Code to aid decoding.
+// The bitmap includes the "quiet zone" around the QR Code to aid decoding, QuietZone modules wide
 func (q *QRCode) Bitmap() [][]bool {
 	// Build QR code
 	q.encode()
 	return q.symbol.bitmap()
 }
 
+// Reports whether the QR Code has more colours than BackgroundColor and ForegroundColor, and so cannot be paletted
+func (q *QRCode) truecolor() bool {
+	return q.Style != nil || q.Fill != nil || q.ModuleColor != nil || q.logo != nil
+}
+
+// Returns the width of the quiet zone drawn on each side of the symbol, in modules
+func (q *QRCode) quietZoneSize() int {
+	if q.DisableBorder || q.QuietZone < 0 {
+		return 0
+	}
+	return q.QuietZone
+}
+
 // Completes the steps required to encode the QR Code. These include adding the terminator bits and padding,
 // splitting the data into blocks and applying the error correction, and selecting the best data mask
 func (q *QRCode) encode() {
+	q.encodeWith(&encodeBuffers{})
+}
+
+// Storage for the intermediate results of encode(), which an Encoder reuses between QR Codes
+type encodeBuffers struct {
+	sequential       bool           // Evaluate the mask candidates in the calling goroutine only
+	codewords        []byte         // Data and error correction codewords, see appendBlocks()
+	encoded          *bitset.Bitset // Final data sequence
+	functionPatterns *symbol        // Function patterns of the last version encoded
+	candidates       [numMasks]*symbol
+}
+
+// Encodes the QR Code as encode() does, using b for the intermediate results
+// The chosen symbol is one of the candidates in b, so it is only valid until b is used again
+func (q *QRCode) encodeWith(b *encodeBuffers) {
 	numTerminatorBits := q.version.numTerminatorBitsRequired(q.data.Len())
 	q.addTerminatorBits(numTerminatorBits)
 	q.addPadding()
-	encoded := q.encodeBlocks()
-	const numMasks int = 8
-	penalty := 0
-	for mask := 0; mask < numMasks; mask++ {
-		var s *symbol
-		var err error
-		s, err = buildRegularSymbol(q.version, mask, encoded, !q.DisableBorder)
-		if err != nil {
-			log.Panic(err.Error())
+	if b.encoded == nil {
+		b.encoded = bitset.New()
+	}
+	b.encoded.Reset()
+	b.codewords = appendBlocks(b.encoded, q.version, q.data, b.codewords)
+	best := bestMask(q.evaluateMasks(b))
+	q.symbol = b.candidates[best]
+	q.mask = best
+	if q.logo != nil {
+		q.logo.clear(q.symbol)
+	}
+}
+
+// Returns the mask with the lowest penalty score
+// Ties are resolved in favour of the lowest mask, so the choice does not depend on the evaluation order
+func bestMask(penalties [numMasks]int) int {
+	best := 0
+	for mask := 1; mask < numMasks; mask++ {
+		if penalties[mask] < penalties[best] {
+			best = mask
+		}
+	}
+	return best
+}
+
+// Builds a symbol for each data mask into b.candidates and returns their penalty scores, indexed by mask
+// The function patterns are built once and shared between the candidates
+// For larger versions the candidates are evaluated concurrently, using at most GOMAXPROCS goroutines
+func (q *QRCode) evaluateMasks(b *encodeBuffers) (penalties [numMasks]int) {
+	quietZoneSize := q.quietZoneSize()
+	fp := b.functionPatterns
+	if fp == nil || fp.symbolSize != q.version.symbolSize() || fp.quietZoneSize != quietZoneSize {
+		if fp == nil {
+			fp = &symbol{}
 		}
-		numEmptyModules := s.numEmptyModules()
-		if numEmptyModules != 0 {
-			log.Panicf("bug: numEmptyModules is %d (expected 0) (version=%d)",
-				numEmptyModules, q.VersionNumber)
+		b.functionPatterns = buildFunctionPatterns(q.version, quietZoneSize, fp)
+	}
+	for mask := range b.candidates {
+		if b.candidates[mask] == nil {
+			b.candidates[mask] = &symbol{}
 		}
-		p := s.penaltyScore()
-		if q.symbol == nil || p < penalty {
-			q.symbol = s
-			q.mask = mask
-			penalty = p
+	}
+	var errs [numMasks]error
+	workers := runtime.GOMAXPROCS(0)
+	if workers > numMasks {
+		workers = numMasks
+	}
+	if b.sequential || workers < 2 || q.version.version < minParallelMaskVersion {
+		for mask := 0; mask < numMasks; mask++ {
+			penalties[mask], errs[mask] = q.evaluateMask(b, mask)
 		}
+	} else {
+		penalties, errs = q.evaluateMasksConcurrently(b, workers)
 	}
+	// Errors are raised in the caller's goroutine, so they can be recovered as before
+	for _, err := range errs {
+		if err != nil {
+			log.Panic(err.Error())
+		}
+	}
+	return penalties
+}
+
+// Evaluates the mask candidates as evaluateMask() does, using workers goroutines
+func (q *QRCode) evaluateMasksConcurrently(b *encodeBuffers, workers int) (penalties [numMasks]int, errs [numMasks]error) {
+	var wg sync.WaitGroup
+	masks := make(chan int, numMasks)
+	for mask := 0; mask < numMasks; mask++ {
+		masks <- mask
+	}
+	close(masks)
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for mask := range masks {
+				penalties[mask], errs[mask] = q.evaluateMask(b, mask)
+			}
+		}()
+	}
+	wg.Wait()
+	return penalties, errs
+}
+
+// Builds the symbol for mask into b.candidates[mask] and returns its penalty score
+func (q *QRCode) evaluateMask(b *encodeBuffers, mask int) (int, error) {
+	s, err := buildMaskedSymbol(q.version, mask, b.encoded, b.functionPatterns, b.candidates[mask])
+	if err != nil {
+		return 0, err
+	}
+	numEmptyModules := s.numEmptyModules()
+	if numEmptyModules != 0 {
+		return 0, fmt.Errorf("bug: numEmptyModules is %d (expected 0) (version=%d)",
+			numEmptyModules, q.VersionNumber)
+	}
+	return s.penaltyScore(), nil
 }
 
 // Returns the QR Code as an image.Image
@@ -238,37 +374,50 @@ func (q *QRCode) encode() {
 // As an alternative, a variable sized image can be generated instead: A negative size causes a variable sized image to be returned
 // The image returned is the minimum size required for the QR Code. Choose a larger negative number to increase the scale of the image
 // e.g. a size of -5 causes each module (QR Code "pixel") to be 5px in size
+// Fixed size images map each pixel to the nearest module, so modules can differ in size by a pixel
+// Set IntegerScaling to draw every module with the same whole number of pixels instead, see ImageLayout()
+// The image is paletted, with BackgroundColor and ForegroundColor as its only colours. See NRGBAImage() for a truecolour image
+// If Style or a logo is set, the image is a truecolour *image.NRGBA, see SetLogo()
 func (q *QRCode) Image(size int) image.Image {
 	// Build QR code
 	q.encode()
-	// Minimum pixels (both width and height) required
-	realSize := q.symbol.size
-	// Variable size support
-	if size < 0 {
-		size = size * -1 * realSize
-	}
-	// Actual pixels available to draw the symbol. Automatically increase the image size if it's not large enough
-	if size < realSize {
-		size = realSize
-	}
-	// Output image
-	rect := image.Rectangle{Min: image.Point{0, 0}, Max: image.Point{size, size}}
-	// Saves a few bytes to have them in this order
-	p := color.Palette([]color.Color{q.BackgroundColor, q.ForegroundColor})
-	img := image.NewPaletted(rect, p)
-	fgClr := uint8(img.Palette.Index(q.ForegroundColor))
-	// QR code bitmap
-	bitmap := q.symbol.bitmap()
-	// Map each image pixel to the nearest QR code module
-	modulesPerPixel := float64(realSize) / float64(size)
+	if q.truecolor() {
+		return q.drawNRGBA(q.ImageLayout(size))
+	}
+	return drawPaletted(q.symbol, q.ImageLayout(size), q.BackgroundColor, q.ForegroundColor, nil)
+}
+
+// Draws the symbol with layout l, into dst if it has the required size, or else into a new image
+// The image is returned
+func drawPaletted(s *symbol, l ImageLayout, background, foreground color.Color, dst *image.Paletted) *image.Paletted {
+	size := l.Size
+	img := dst
+	if img != nil && img.Rect.Dx() == size && img.Rect.Dy() == size && len(img.Palette) == 2 {
+		img.Palette[0], img.Palette[1] = background, foreground
+		for i := range img.Pix {
+			img.Pix[i] = 0
+		}
+	} else {
+		// Output image
+		rect := image.Rectangle{Min: image.Point{0, 0}, Max: image.Point{size, size}}
+		// Saves a few bytes to have them in this order
+		p := color.Palette([]color.Color{background, foreground})
+		img = image.NewPaletted(rect, p)
+	}
+	// The foreground is always palette entry 1, even when it equals the background
+	const fgClr = 1
+	// Map each image pixel to its QR code module
 	for y := 0; y < size; y++ {
-		y2 := int(float64(y) * modulesPerPixel)
+		y2 := l.module(y)
+		row := img.Pix[y*img.Stride : y*img.Stride+size]
+		// Consecutive pixel rows usually map to the same module row
+		if y > 0 && l.module(y-1) == y2 {
+			copy(row, img.Pix[(y-1)*img.Stride:])
+			continue
+		}
 		for x := 0; x < size; x++ {
-			x2 := int(float64(x) * modulesPerPixel)
-			v := bitmap[y2][x2]
-			if v {
-				pos := img.PixOffset(x, y)
-				img.Pix[pos] = fgClr
+			if s.bitmapAt(l.module(x), y2) {
+				row[x] = fgClr
 			}
 		}
 	}
@@ -281,10 +430,8 @@ func (q *QRCode) Image(size int) image.Image {
 // Negative values for size cause a variable sized image to be returned:
 // See the documentation for Image()
 func (q *QRCode) PNG(size int) ([]byte, error) {
-	img := q.Image(size)
-	encoder := png.Encoder{CompressionLevel: png.BestCompression}
 	var b bytes.Buffer
-	err := encoder.Encode(&b, img)
+	err := q.Write(size, &b)
 	if err != nil {
 		return nil, err
 	}
@@ -294,79 +441,47 @@ func (q *QRCode) PNG(size int) ([]byte, error) {
 // Writes the QR Code as a PNG image to io.Writer size is both the image width and height in pixels
 // If size is too small then a larger image is silently written
 // Negative values for size cause a variable sized image to be written: See the documentation for Image()
+// The image is streamed from the QR Code a row at a time, so large images are not built in memory first
+// An error is returned if BackgroundColor and ForegroundColor are too similar to tell apart
 func (q *QRCode) Write(size int, out io.Writer) error {
-	png, err := q.PNG(size)
-	if err != nil {
+	if err := q.validateContrast(); err != nil {
 		return err
 	}
-	_, err = out.Write(png)
-	return err
+	if q.truecolor() {
+		return encodePNG(out, q.Image(size))
+	}
+	// Build QR code
+	q.encode()
+	return writePNG(out, q.symbol, q.ImageLayout(size), q.BackgroundColor, q.ForegroundColor)
 }
 
 // Writes the QR Code as a PNG image to the specified file size is both the image width and height in pixels
 // If size is too small then a larger image is silently written
 // Negative values for size cause a variable sized image to be written: See the documentation for Image()
 func (q *QRCode) WriteFile(size int, filename string) error {
-	png, err := q.PNG(size)
+	// Check the colours before truncating the file, so that a rejected pair leaves it unchanged
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(0644))
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filename, png, os.FileMode(0644))
+	err = q.Write(size, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
 }
 
 // Produces a multi-line string that forms a QR-code image
 func (q *QRCode) ToString(inverseColor bool) string {
-	bits := q.Bitmap()
-	var buf bytes.Buffer
-	for y := range bits {
-		for x := range bits[y] {
-			if bits[y][x] != inverseColor {
-				buf.WriteString("  ")
-			} else {
-				buf.WriteString("██")
-			}
-		}
-		buf.WriteString("\n")
-	}
-	return buf.String()
+	return blockText(q.Bitmap(), inverseColor)
 }
 
 // Produces a multi-line string that forms a QR-code image, a factor two smaller in x and y then ToString
 func (q *QRCode) ToSmallString(inverseColor bool) string {
-	bits := q.Bitmap()
-	var buf bytes.Buffer
-	// If there is an odd number of rows, the last one needs special treatment
-	for y := 0; y < len(bits)-1; y += 2 {
-		for x := range bits[y] {
-			if bits[y][x] == bits[y+1][x] {
-				if bits[y][x] != inverseColor {
-					buf.WriteString(" ")
-				} else {
-					buf.WriteString("█")
-				}
-			} else {
-				if bits[y][x] != inverseColor {
-					buf.WriteString("▄")
-				} else {
-					buf.WriteString("▀")
-				}
-			}
-		}
-		buf.WriteString("\n")
-	}
-	// Special treatment for the last row if odd
-	if len(bits)%2 == 1 {
-		y := len(bits) - 1
-		for x := range bits[y] {
-			if bits[y][x] != inverseColor {
-				buf.WriteString(" ")
-			} else {
-				buf.WriteString("▀")
-			}
-		}
-		buf.WriteString("\n")
-	}
-	return buf.String()
+	return smallBlockText(q.Bitmap(), inverseColor)
 }
 
 // Encode a QR Code and return a raw PNG image
@@ -408,12 +523,16 @@ func WriteFile(content string, level RecoveryLevel, size int, filename string) e
 // Size is both the image width and height in pixels
 // If size is too small then a larger image is silently written
 // Negative values for size cause a variable sized image to be written: See the documentation for Image()
+// An error is returned if the colours are too similar to tell apart, see NRGBAImage()
 func WriteColorFile(content string, level RecoveryLevel, size int, background,
 	foreground color.Color, filename string) error {
 	q, err := New(content, level)
 	if err != nil {
 		return err
 	}
+	if err := validateColorPair(background, foreground); err != nil {
+		return err
+	}
 	q.BackgroundColor = background
 	q.ForegroundColor = foreground
 	return q.WriteFile(size, filename)
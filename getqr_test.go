@@ -0,0 +1,100 @@
+package getqr
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBestMask(t *testing.T) {
+	tests := []struct {
+		penalties [numMasks]int
+		want      int
+	}{
+		{[numMasks]int{5, 4, 3, 2, 1, 2, 3, 4}, 4},
+		{[numMasks]int{9, 3, 7, 3, 8, 3, 9, 9}, 1},
+		{[numMasks]int{6, 6, 6, 6, 6, 6, 6, 6}, 0},
+		{[numMasks]int{9, 9, 9, 9, 9, 9, 2, 2}, 6},
+	}
+	for _, test := range tests {
+		if got := bestMask(test.penalties); got != test.want {
+			t.Errorf("bestMask(%v) = %d, want %d", test.penalties, got, test.want)
+		}
+	}
+}
+
+func TestEncodeConcurrentMatchesSequential(t *testing.T) {
+	// Enough goroutines for the concurrent path, whatever the number of CPUs
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(numMasks))
+	for _, version := range []int{minParallelMaskVersion, 17, 25, 40} {
+		for _, level := range []RecoveryLevel{Low, Medium, High, Highest} {
+			content := strings.Repeat("getqr", version)
+			sequential, err := NewWithForcedVersion(content, version, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			concurrent, err := NewWithForcedVersion(content, version, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sb, cb := &encodeBuffers{sequential: true}, &encodeBuffers{}
+			sequential.encodeWith(sb)
+			concurrent.encodeWith(cb)
+			if sp, cp := sequential.evaluateMasks(sb), concurrent.evaluateMasks(cb); sp != cp {
+				t.Errorf("version %d level %d: concurrent penalties %v, want %v", version, level, cp, sp)
+			}
+			if concurrent.mask != sequential.mask {
+				t.Errorf("version %d level %d: concurrent encoding chose mask %d, want %d", version, level, concurrent.mask, sequential.mask)
+			}
+			if !reflect.DeepEqual(concurrent.symbol.bitmap(), sequential.symbol.bitmap()) {
+				t.Errorf("version %d level %d: concurrent encoding differs from sequential", version, level)
+			}
+		}
+	}
+}
+
+func TestQuietZone(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.QuietZone != 4 {
+		t.Fatalf("New() set a quiet zone of %d modules, want 4", q.QuietZone)
+	}
+	symbolSize := q.version.symbolSize()
+	for _, quietZone := range []int{0, 1, 2, 4, 8} {
+		q.QuietZone = quietZone
+		bitmap := q.Bitmap()
+		size := symbolSize + 2*quietZone
+		if len(bitmap) != size || len(bitmap[0]) != size {
+			t.Fatalf("quiet zone %d: got %dx%d bitmap, want %dx%d", quietZone, len(bitmap[0]), len(bitmap), size, size)
+		}
+		for y := range bitmap {
+			for x := range bitmap[y] {
+				inside := x >= quietZone && y >= quietZone && x < quietZone+symbolSize && y < quietZone+symbolSize
+				if bitmap[y][x] && !inside {
+					t.Fatalf("quiet zone %d: module (%d, %d) is set", quietZone, x, y)
+				}
+			}
+		}
+		// The top left finder pattern starts at the quiet zone
+		if !bitmap[quietZone][quietZone] {
+			t.Errorf("quiet zone %d: finder pattern is not at (%d, %d)", quietZone, quietZone, quietZone)
+		}
+		if got := q.Image(-1).Bounds().Dx(); got != size {
+			t.Errorf("quiet zone %d: got %dpx image, want %dpx", quietZone, got, size)
+		}
+		if got := strings.Count(q.ToString(false), "\n"); got != size {
+			t.Errorf("quiet zone %d: ToString() has %d lines, want %d", quietZone, got, size)
+		}
+		if got := strings.Count(q.ToSmallString(false), "\n"); got != (size+1)/2 {
+			t.Errorf("quiet zone %d: ToSmallString() has %d lines, want %d", quietZone, got, (size+1)/2)
+		}
+	}
+	q.QuietZone = 8
+	q.DisableBorder = true
+	if got := len(q.Bitmap()); got != symbolSize {
+		t.Errorf("DisableBorder: got %d rows, want %d", got, symbolSize)
+	}
+}
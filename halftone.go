@@ -0,0 +1,179 @@
+package getqr
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Number of sub-modules along each side of a module of a halftone QR Code
+const halftoneScale = 3
+
+// Halftone makes a QR Code resemble a picture, see HalftoneImage()
+type Halftone struct {
+	Image    image.Image // Picture stretched over the symbol, excluding the quiet zone
+	BestMask bool        // Use the data mask whose modules best match the picture, instead of the standard choice
+}
+
+// Returns the QR Code as a halftone image resembling the picture of h
+// Each module is split into 3x3 sub-modules. The centre sub-module of a data module carries its data,
+// and the others follow the picture, dithered to BackgroundColor and ForegroundColor
+// The finder, alignment and timing patterns, and the format and version information, are drawn as whole modules
+// Scanners sample the centre of each module, so the code remains readable, at the cost of some robustness
+// Size is the image width and height in pixels, or if negative, the width of a sub-module, so that -1 is the smallest image
+// As for Image(), a fixed size maps each pixel to the nearest sub-module, unless IntegerScaling is set. The image is paletted, as Image() draws it
+// Style, Fill and ModuleColor are ignored. An error is returned if the QR Code has a logo
+func (q *QRCode) HalftoneImage(size int, h *Halftone) (*image.Paletted, error) {
+	if h == nil || h.Image == nil || h.Image.Bounds().Empty() {
+		return nil, errors.New("halftone has no image")
+	}
+	if q.logo != nil {
+		return nil, errors.New("halftone QR Codes cannot have a logo")
+	}
+	if err := q.validateContrast(); err != nil {
+		return nil, err
+	}
+	// Build QR code, keeping every mask candidate
+	b := &encodeBuffers{}
+	q.encodeWith(b)
+	n := q.symbol.symbolSize
+	luminance := sampleLuminance(h.Image, n*halftoneScale)
+	roles := buildModuleRoles(q.version)
+	if h.BestMask {
+		best, bestMismatch := 0, math.Inf(1)
+		for mask, s := range b.candidates {
+			if m := halftoneMismatch(s, roles, luminance); m < bestMismatch {
+				best, bestMismatch = mask, m
+			}
+		}
+		q.symbol = b.candidates[best]
+		q.mask = best
+	}
+	sub := halftone(q.symbol, roles, luminance)
+	qz := q.symbol.quietZoneSize
+	bitmap := n + 2*qz
+	if size < 0 {
+		// Every sub-module is the same whole number of pixels
+		size = -size * bitmap * halftoneScale
+	}
+	l := newImageLayout(size, bitmap*halftoneScale, q.IntegerScaling)
+	img := image.NewPaletted(image.Rect(0, 0, l.Size, l.Size), color.Palette{q.BackgroundColor, q.ForegroundColor})
+	// Returns the sub-module of the symbol drawn at pixel p, which is out of range in the quiet zone
+	subModule := func(p int) int {
+		m := l.module(p)
+		if m < 0 {
+			return -1
+		}
+		return m - qz*halftoneScale
+	}
+	width := n * halftoneScale
+	for y := 0; y < l.Size; y++ {
+		sy := subModule(y)
+		if sy < 0 || sy >= width {
+			continue
+		}
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < l.Size; x++ {
+			if sx := subModule(x); sx >= 0 && sx < width && sub[sy*width+sx] {
+				row[x] = 1
+			}
+		}
+	}
+	return img, nil
+}
+
+// Returns the luminance of img, composited over white, averaged over each cell of a size x size grid stretched over it
+func sampleLuminance(img image.Image, size int) []float64 {
+	b := img.Bounds()
+	sum := make([]float64, size*size)
+	count := make([]int, size*size)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		cy := (y - b.Min.Y) * size / b.Dy()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			cx := (x - b.Min.X) * size / b.Dx()
+			sum[cy*size+cx] += relativeLuminance(compositeOver(img.At(x, y), color.White))
+			count[cy*size+cx]++
+		}
+	}
+	// A picture smaller than the grid leaves cells empty, which take the nearest pixel instead
+	for i := range sum {
+		if count[i] > 0 {
+			sum[i] /= float64(count[i])
+			continue
+		}
+		x := b.Min.X + (i%size)*b.Dx()/size
+		y := b.Min.Y + (i/size)*b.Dy()/size
+		sum[i] = relativeLuminance(compositeOver(img.At(x, y), color.White))
+	}
+	return sum
+}
+
+// Returns how badly the data modules of s match the picture with sub-module luminance,
+// as the total difference between their colours and the average luminance under them
+func halftoneMismatch(s *symbol, roles []ModuleRole, luminance []float64) float64 {
+	n := s.symbolSize
+	width := n * halftoneScale
+	mismatch := 0.0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if roles[y*n+x] != RoleData {
+				continue
+			}
+			l := 0.0
+			for i := 0; i < halftoneScale; i++ {
+				for j := 0; j < halftoneScale; j++ {
+					l += luminance[(y*halftoneScale+i)*width+x*halftoneScale+j]
+				}
+			}
+			l /= halftoneScale * halftoneScale
+			if s.get(x, y) {
+				mismatch += l
+			} else {
+				mismatch += 1 - l
+			}
+		}
+	}
+	return mismatch
+}
+
+// Returns the sub-modules of the halftone image of s, true where dark, in rows of width symbolSize*halftoneScale
+// The free sub-modules are dithered from luminance using Floyd-Steinberg error diffusion
+// The centres of the data modules diffuse their error too, so the picture's tone is kept around them
+func halftone(s *symbol, roles []ModuleRole, luminance []float64) []bool {
+	n := s.symbolSize
+	width := n * halftoneScale
+	sub := make([]bool, width*width)
+	// Error carried to the current and next rows
+	errs := [2][]float64{make([]float64, width+2), make([]float64, width+2)}
+	for y := 0; y < width; y++ {
+		cur, next := errs[y%2], errs[(y+1)%2]
+		for i := range next {
+			next[i] = 0
+		}
+		my := y / halftoneScale
+		for x := 0; x < width; x++ {
+			mx := x / halftoneScale
+			v := luminance[y*width+x] + cur[x+1]
+			if roles[my*n+mx] != RoleData {
+				// Function patterns are drawn whole, and take no part in the dithering
+				sub[y*width+x] = s.get(mx, my)
+				continue
+			}
+			dark := v < 0.5
+			if y%halftoneScale == halftoneScale/2 && x%halftoneScale == halftoneScale/2 {
+				dark = s.get(mx, my)
+			}
+			sub[y*width+x] = dark
+			e := v
+			if !dark {
+				e = v - 1
+			}
+			cur[x+2] += e * 7 / 16
+			next[x] += e * 3 / 16
+			next[x+1] += e * 5 / 16
+			next[x+2] += e / 16
+		}
+	}
+	return sub
+}
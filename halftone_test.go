@@ -0,0 +1,105 @@
+package getqr
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// Returns a picture dark on the left half and light on the right
+func testHalftonePicture() image.Image {
+	img := image.NewGray(image.Rect(0, 0, 60, 60))
+	for y := 0; y < 60; y++ {
+		for x := 30; x < 60; x++ {
+			img.SetGray(x, y, color.Gray{0xff})
+		}
+	}
+	return img
+}
+
+func TestHalftoneImage(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", High)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := q.HalftoneImage(-1, &Halftone{Image: testHalftonePicture()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := q.Bitmap()
+	if want := len(bitmap) * halftoneScale; img.Rect.Dx() != want {
+		t.Fatalf("got a %v image, want %d pixels wide", img.Rect, want)
+	}
+	n := q.version.symbolSize()
+	qz := q.QuietZone
+	roles := buildModuleRoles(q.version)
+	// Dark free sub-modules on each half of the picture
+	var dark [2]int
+	for y := range bitmap {
+		for x := range bitmap[y] {
+			// The quiet zone is drawn whole, like a function pattern
+			role := RoleFinder
+			if x >= qz && y >= qz && x < qz+n && y < qz+n {
+				role = roles[(y-qz)*n+x-qz]
+			}
+			for i := 0; i < halftoneScale; i++ {
+				for j := 0; j < halftoneScale; j++ {
+					got := img.ColorIndexAt(x*halftoneScale+j, y*halftoneScale+i) == 1
+					centre := i == halftoneScale/2 && j == halftoneScale/2
+					if role != RoleData || centre {
+						if got != bitmap[y][x] {
+							t.Fatalf("sub-module (%d, %d) of module (%d, %d) with role %v: got %v, want %v", j, i, x, y, role, got, bitmap[y][x])
+						}
+					} else if got {
+						dark[(x-qz)*2/n]++
+					}
+				}
+			}
+		}
+	}
+	if dark[0] < 8*dark[1] {
+		t.Errorf("got %d dark sub-modules on the dark half of the picture and %d on the light half", dark[0], dark[1])
+	}
+
+	// A negative size is the width of a sub-module
+	img, err = q.HalftoneImage(-2, &Halftone{Image: testHalftonePicture()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 * len(bitmap) * halftoneScale; img.Rect.Dx() != want {
+		t.Errorf("got a %v image for size -2, want %d pixels wide", img.Rect, want)
+	}
+
+	if err := q.SetLogo(&Logo{Image: testLogo(4, 4, color.Black)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.HalftoneImage(100, &Halftone{Image: testHalftonePicture()}); err == nil {
+		t.Error("expected an error for a QR Code with a logo")
+	}
+	if _, err := q.HalftoneImage(100, &Halftone{}); err == nil {
+		t.Error("expected an error without a picture")
+	}
+}
+
+func TestHalftoneBestMask(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", High)
+	if err != nil {
+		t.Fatal(err)
+	}
+	picture := testHalftonePicture()
+	if _, err := q.HalftoneImage(-1, &Halftone{Image: picture, BestMask: true}); err != nil {
+		t.Fatal(err)
+	}
+	roles := buildModuleRoles(q.version)
+	luminance := sampleLuminance(picture, q.version.symbolSize()*halftoneScale)
+	chosen := halftoneMismatch(q.symbol, roles, luminance)
+	for mask := 0; mask < numMasks; mask++ {
+		s, err := buildRegularSymbol(q.version, mask, q.encodeBlocks(), q.QuietZone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := halftoneMismatch(s, roles, luminance); m < chosen {
+			t.Errorf("mask %d mismatches the picture by %v, less than the chosen mask %d by %v", mask, m, q.mask, chosen)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package getqr
+
+// ImageLayout describes how the modules of a QR Code are mapped to the pixels of an image
+type ImageLayout struct {
+	Size       int // Width and height of the image in pixels
+	ModuleSize int // Width and height of each module in pixels. Zero unless IntegerScaling is set, as modules then vary in size
+	Offset     int // Distance in pixels from the top and left edges of the image to the quiet zone, or to the symbol if there is no quiet zone
+
+	modules         int     // Width and height of the bitmap in modules, including the quiet zones
+	modulesPerPixel float64 // Used without IntegerScaling
+}
+
+// Returns the layout of a size x size image of a bitmap of modules x modules. See the documentation for Image() for the meaning of size
+// If integer is set, every module is ModuleSize pixels wide and the bitmap is centred in the image
+// Otherwise each pixel maps to the nearest module, and the bitmap fills the image
+func newImageLayout(size int, modules int, integer bool) ImageLayout {
+	// Variable size support
+	if size < 0 {
+		size = size * -1 * modules
+	}
+	// Actual pixels available to draw the symbol. Automatically increase the image size if it's not large enough
+	if size < modules {
+		size = modules
+	}
+	l := ImageLayout{Size: size, modules: modules}
+	if integer {
+		l.ModuleSize = size / modules
+		l.Offset = (size - l.ModuleSize*modules) / 2
+	} else {
+		l.modulesPerPixel = float64(modules) / float64(size)
+	}
+	return l
+}
+
+// Returns the module, along either axis, drawn at pixel p. The result is -1 for pixels in the margin around the bitmap
+func (l ImageLayout) module(p int) int {
+	if l.ModuleSize == 0 {
+		return int(float64(p) * l.modulesPerPixel)
+	}
+	p -= l.Offset
+	if p < 0 || p >= l.ModuleSize*l.modules {
+		return -1
+	}
+	return p / l.ModuleSize
+}
+
+// Returns the position, in modules from the top left corner of the bitmap, of the centre of pixel p along either axis
+func (l ImageLayout) moduleCoord(p int) float64 {
+	if l.ModuleSize == 0 {
+		return (float64(p) + 0.5) * l.modulesPerPixel
+	}
+	return (float64(p-l.Offset) + 0.5) / float64(l.ModuleSize)
+}
+
+// Returns the positions, in modules from the top left corner of the bitmap, of the edges of pixel p along either axis
+func (l ImageLayout) moduleSpan(p int) (float64, float64) {
+	if l.ModuleSize == 0 {
+		return float64(p) * l.modulesPerPixel, float64(p+1) * l.modulesPerPixel
+	}
+	return float64(p-l.Offset) / float64(l.ModuleSize), float64(p+1-l.Offset) / float64(l.ModuleSize)
+}
+
+// Returns the pixel position of the position m in modules from the top left corner of the bitmap
+func (l ImageLayout) pixelCoord(m float64) float64 {
+	if l.ModuleSize == 0 {
+		return m / l.modulesPerPixel
+	}
+	return float64(l.Offset) + m*float64(l.ModuleSize)
+}
+
+// Returns the layout of an image of the QR Code, as drawn by Image(), PNG() and Write() for size
+// With IntegerScaling set, the layout gives the exact pixel size and position of the modules
+func (q *QRCode) ImageLayout(size int) ImageLayout {
+	return newImageLayout(size, q.bitmapSize(), q.IntegerScaling)
+}
+
+// Returns the width and height of Bitmap(), i.e. the symbol size including the quiet zones
+func (q *QRCode) bitmapSize() int {
+	return q.version.symbolSize() + 2*q.quietZoneSize()
+}
@@ -0,0 +1,60 @@
+package getqr
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestImageLayoutIntegerScaling(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.IntegerScaling = true
+	modules := q.bitmapSize()
+	for _, size := range []int{-3, 10, 97, 256, 1000} {
+		l := q.ImageLayout(size)
+		if l.ModuleSize != l.Size/modules || l.Offset != (l.Size-l.ModuleSize*modules)/2 {
+			t.Errorf("size %d: got module size %d offset %d for %dpx image", size, l.ModuleSize, l.Offset, l.Size)
+			continue
+		}
+		bitmap := q.Bitmap()
+		img := q.Image(size)
+		if img.Bounds().Dx() != l.Size {
+			t.Errorf("size %d: got %dpx image, want %dpx", size, img.Bounds().Dx(), l.Size)
+		}
+		for y := 0; y < l.Size; y++ {
+			for x := 0; x < l.Size; x++ {
+				want := color.Color(q.BackgroundColor)
+				mx, my := x-l.Offset, y-l.Offset
+				inside := mx >= 0 && my >= 0 && mx < l.ModuleSize*modules && my < l.ModuleSize*modules
+				if inside && bitmap[my/l.ModuleSize][mx/l.ModuleSize] {
+					want = q.ForegroundColor
+				}
+				if !sameColor(img.At(x, y), want) {
+					t.Fatalf("size %d: pixel (%d, %d) has the wrong colour", size, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestImageLayoutDefault(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := q.ImageLayout(256)
+	if l.Size != 256 || l.ModuleSize != 0 || l.Offset != 0 {
+		t.Errorf("got %+v, want a 256px image filled by the symbol", l)
+	}
+	if l := q.ImageLayout(-4); l.Size != 4*q.bitmapSize() {
+		t.Errorf("got %dpx image for size -4, want %dpx", l.Size, 4*q.bitmapSize())
+	}
+}
+
+func sameColor(a, b color.Color) bool {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
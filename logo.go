@@ -0,0 +1,242 @@
+package getqr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Default width of a logo as a fraction of the symbol width
+const defaultLogoSize = 0.2
+
+// Logo is an image drawn over the centre of a QR Code, see SetLogo()
+type Logo struct {
+	Image  image.Image
+	Size   float64 // Width of the logo as a fraction of the symbol width, excluding the quiet zone. Zero means 0.2
+	Margin int     // Width in modules of the light margin cleared around the logo. Zero means 1
+}
+
+// logoPlacement is a Logo positioned on a symbol. Positions are in modules from the top left corner of the symbol
+type logoPlacement struct {
+	image      image.Image
+	x, y, w, h float64       // Area covered by the logo image
+	x0, y0     int           // First cleared module
+	x1, y1     int           // Last cleared module + 1
+	alignment  []image.Point // Alignment pattern modules within the cleared area, which are neither cleared nor covered
+}
+
+// Places logo over the centre of the QR Code, clearing the modules under it and its margin
+// The cleared modules are lost to the decoder, and are recovered using the error correction codewords
+// Alignment patterns are not codewords, so they are left showing through the logo
+// An error is returned if this needs more codewords than the recovery level can correct in any block,
+// or if the logo would cover the finder, timing, format or version information patterns
+// The logo is drawn by the raster outputs and SVG(). A nil logo removes the logo
+func (q *QRCode) SetLogo(logo *Logo) error {
+	if logo == nil {
+		q.logo = nil
+		return nil
+	}
+	p, err := placeLogo(q.version, logo)
+	if err != nil {
+		return err
+	}
+	q.logo = p
+	return nil
+}
+
+// Constructs a QR Code with a logo, see SetLogo()
+// If the logo covers too much of the QR Code at level, the recovery level is raised, and then the version, until it fits
+// An error occurs if the content is too long, or the logo doesn't fit at any level and version
+func NewWithLogo(content string, level RecoveryLevel, logo *Logo) (*QRCode, error) {
+	q, err := New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	logoErr := q.SetLogo(logo)
+	if logoErr == nil {
+		return q, nil
+	}
+	for level++; level <= Highest; level++ {
+		next, err := New(content, level)
+		if err != nil {
+			// The content is too long for higher levels, so try larger versions at the last level that fitted
+			break
+		}
+		q = next
+		if logoErr = q.SetLogo(logo); logoErr == nil {
+			return q, nil
+		}
+	}
+	for version := q.version.version + 1; version <= 40; version++ {
+		next, err := NewWithForcedVersion(content, version, q.Level)
+		if err != nil {
+			return nil, err
+		}
+		if logoErr = next.SetLogo(logo); logoErr == nil {
+			return next, nil
+		}
+	}
+	return nil, logoErr
+}
+
+// Returns the placement of logo on a symbol of version, checking that the symbol remains decodable
+func placeLogo(version qrCodeVersion, logo *Logo) (*logoPlacement, error) {
+	if logo.Image == nil || logo.Image.Bounds().Empty() {
+		return nil, errors.New("logo has no image")
+	}
+	width := logo.Size
+	if width == 0 {
+		width = defaultLogoSize
+	} else if width < 0 || width > 1 {
+		return nil, fmt.Errorf("invalid logo size %v (expected a fraction of the symbol width)", width)
+	}
+	margin := logo.Margin
+	if margin == 0 {
+		margin = 1
+	} else if margin < 0 {
+		return nil, fmt.Errorf("invalid logo margin %d", margin)
+	}
+	size := version.symbolSize()
+	bounds := logo.Image.Bounds()
+	p := &logoPlacement{image: logo.Image}
+	p.w = width * float64(size)
+	p.h = p.w * float64(bounds.Dy()) / float64(bounds.Dx())
+	p.x = (float64(size) - p.w) / 2
+	p.y = (float64(size) - p.h) / 2
+	p.x0 = int(math.Floor(p.x)) - margin
+	p.y0 = int(math.Floor(p.y)) - margin
+	p.x1 = int(math.Ceil(p.x+p.w)) + margin
+	p.y1 = int(math.Ceil(p.y+p.h)) + margin
+	if p.x0 < 0 || p.y0 < 0 || p.x1 > size || p.y1 > size {
+		return nil, errors.New("logo is larger than the symbol")
+	}
+	roles := buildModuleRoles(version)
+	bitIndex := buildDataBitIndex(version, roles)
+	blocks := codewordBlocks(version)
+	// Codewords damaged by clearing the modules, counted once each, and their number per block
+	damaged := make(map[int]bool)
+	perBlock := make([]int, len(blocks))
+	for y := p.y0; y < p.y1; y++ {
+		for x := p.x0; x < p.x1; x++ {
+			switch roles[y*size+x] {
+			case RoleFinder, RoleTiming, RoleFormat, RoleVersion:
+				return nil, fmt.Errorf("logo covers the function patterns at module (%d, %d)", x, y)
+			case RoleAlignment:
+				p.alignment = append(p.alignment, image.Pt(x, y))
+			case RoleData:
+				codeword := bitIndex[y*size+x] / 8
+				if codeword >= len(blocks) || damaged[codeword] {
+					// Remainder bits, or already counted
+					continue
+				}
+				damaged[codeword] = true
+				perBlock[blocks[codeword]]++
+			}
+		}
+	}
+	block := 0
+	for _, b := range version.block {
+		// Errors at unknown positions, up to half the number of error correction codewords, can be corrected,
+		// less those reserved to detect misdecodes in small symbols
+		correctable := (b.numCodewords - b.numDataCodewords - misdecodeCodewords(version)) / 2
+		for j := 0; j < b.numBlocks; j++ {
+			if perBlock[block] > correctable {
+				return nil, fmt.Errorf("logo damages %d codewords of block %d, but only %d per block can be corrected in version %d at this level",
+					perBlock[block], block, correctable, version.version)
+			}
+			block++
+		}
+	}
+	return p, nil
+}
+
+// Returns the number of error correction codewords of version reserved for misdecode protection, p in ISO/IEC 18004 table 9
+func misdecodeCodewords(version qrCodeVersion) int {
+	switch {
+	case version.version == 1 && version.level == Low:
+		return 3
+	case version.version == 1 && version.level == Medium, version.version == 2 && version.level == Low:
+		return 2
+	case version.version == 1, version.version == 3 && version.level == Low:
+		return 1
+	}
+	return 0
+}
+
+// Returns the block of each codeword of version, in the interleaved order of appendBlocks()
+func codewordBlocks(version qrCodeVersion) []int {
+	maxDataCodewords := 0
+	maxErrorCodewords := 0
+	for _, b := range version.block {
+		maxDataCodewords = max(maxDataCodewords, b.numDataCodewords)
+		maxErrorCodewords = max(maxErrorCodewords, b.numCodewords-b.numDataCodewords)
+	}
+	var blocks []int
+	for i := 0; i < maxDataCodewords+maxErrorCodewords; i++ {
+		block := 0
+		for _, b := range version.block {
+			for j := 0; j < b.numBlocks; j++ {
+				offset := i
+				if i >= maxDataCodewords {
+					offset = b.numDataCodewords + i - maxDataCodewords
+				}
+				if (i < maxDataCodewords && offset < b.numDataCodewords) ||
+					(i >= maxDataCodewords && offset < b.numCodewords) {
+					blocks = append(blocks, block)
+				}
+				block++
+			}
+		}
+	}
+	return blocks
+}
+
+// Clears the modules under the logo and its margin, other than alignment patterns
+func (p *logoPlacement) clear(s *symbol) {
+	for y := p.y0; y < p.y1; y++ {
+		for x := p.x0; x < p.x1; x++ {
+			if !p.isAlignment(x, y) {
+				s.set(x, y, false)
+			}
+		}
+	}
+}
+
+// Reports whether the module at (x, y) is part of an alignment pattern under the logo
+func (p *logoPlacement) isAlignment(x, y int) bool {
+	for _, a := range p.alignment {
+		if a.X == x && a.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
+// Draws the logo image over dst, an image with layout l of a symbol with a quiet zone quietZoneSize modules wide
+// The logo is scaled to fit its area, using the nearest pixel. Pixels of alignment pattern modules are left as they are
+func (p *logoPlacement) draw(dst draw.Image, l ImageLayout, quietZoneSize int) {
+	qz := float64(quietZoneSize)
+	r := image.Rect(int(math.Round(l.pixelCoord(p.x+qz))), int(math.Round(l.pixelCoord(p.y+qz))),
+		int(math.Round(l.pixelCoord(p.x+p.w+qz))), int(math.Round(l.pixelCoord(p.y+p.h+qz))))
+	// Only the part of the logo within dst is scaled, as dst may be a few rows of the image
+	clip := r.Intersect(dst.Bounds())
+	if clip.Empty() {
+		return
+	}
+	src := p.image
+	sb := src.Bounds()
+	scaled := image.NewNRGBA(clip)
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		sy := sb.Min.Y + (y-r.Min.Y)*sb.Dy()/r.Dy()
+		my := l.module(y) - quietZoneSize
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			if len(p.alignment) > 0 && p.isAlignment(l.module(x)-quietZoneSize, my) {
+				continue
+			}
+			scaled.Set(x, y, src.At(sb.Min.X+(x-r.Min.X)*sb.Dx()/r.Dx(), sy))
+		}
+	}
+	draw.Draw(dst, clip, scaled, clip.Min, draw.Over)
+}
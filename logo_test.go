@@ -0,0 +1,181 @@
+package getqr
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+
+	bitset "github.com/pchchv/getqr/bitset"
+)
+
+func TestBuildDataBitIndex(t *testing.T) {
+	for _, version := range []int{1, 7, 22} {
+		v, data := testEncodedData(t, version)
+		base, err := buildRegularSymbol(v, 0, data, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		index := buildDataBitIndex(v, buildModuleRoles(v))
+		size := v.symbolSize()
+		for bit := 0; bit < data.Len(); bit += 5 {
+			// Flipping a bit of the data changes only the module holding it
+			bits := data.Bits()
+			bits[bit] = !bits[bit]
+			s, err := buildRegularSymbol(v, 0, bitset.New(bits...), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					if changed := s.get(x, y) != base.get(x, y); changed != (index[y*size+x] == bit) {
+						t.Fatalf("version %d bit %d: module (%d, %d) has index %d", version, bit, x, y, index[y*size+x])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCodewordBlocks(t *testing.T) {
+	for _, version := range []int{1, 5, 40} {
+		v := getQRCodeVersion(High, version)
+		counts := map[int]int{}
+		for _, block := range codewordBlocks(*v) {
+			counts[block]++
+		}
+		block := 0
+		for _, b := range v.block {
+			for j := 0; j < b.numBlocks; j++ {
+				if counts[block] != b.numCodewords {
+					t.Errorf("version %d block %d: got %d codewords, want %d", version, block, counts[block], b.numCodewords)
+				}
+				block++
+			}
+		}
+	}
+}
+
+func testLogo(w, h int, c color.Color) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestSetLogo(t *testing.T) {
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	q, err := New("https://github.com/pchchv/getqr", Highest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLogo(&Logo{Image: testLogo(10, 10, red)}); err != nil {
+		t.Fatal(err)
+	}
+	// The modules under the logo are cleared
+	bitmap := q.Bitmap()
+	c := len(bitmap) / 2
+	for y := c - 2; y <= c+2; y++ {
+		for x := c - 2; x <= c+2; x++ {
+			if bitmap[y][x] {
+				t.Fatalf("module (%d, %d) under the logo is set", x, y)
+			}
+		}
+	}
+	img := q.Image(-10)
+	if got := color.NRGBAModel.Convert(img.At(img.Bounds().Dx()/2, img.Bounds().Dy()/2)); got != red {
+		t.Errorf("got %v at the centre of the image, want the logo colour", got)
+	}
+	if _, err := q.SVG(nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := q.PDF(&PDFOptions{ModuleSize: 1}); err == nil {
+		t.Error("PDF() drew a QR Code with a logo")
+	}
+	if err := q.SetLogo(&Logo{Image: testLogo(10, 10, red), Size: 0.9}); err == nil {
+		t.Error("accepted a logo covering the finder patterns")
+	}
+	if err := q.SetLogo(nil); err != nil || q.truecolor() {
+		t.Errorf("logo not removed: %v", err)
+	}
+}
+
+func TestNewWithLogo(t *testing.T) {
+	logo := &Logo{Image: testLogo(10, 10, color.Black), Size: 0.3}
+	q, err := New("https://github.com/pchchv/getqr", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLogo(logo); err == nil {
+		t.Fatal("level L corrected a logo covering 9% of the symbol")
+	}
+	q, err = NewWithLogo("https://github.com/pchchv/getqr", Low, logo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Level == Low {
+		t.Error("recovery level was not raised")
+	}
+	if _, err := NewWithLogo("https://github.com/pchchv/getqr", Low, &Logo{Image: logo.Image, Size: 0.6}); err == nil {
+		t.Error("accepted a logo covering a third of the symbol")
+	}
+}
+
+func TestLogoAlignmentPattern(t *testing.T) {
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	q, err := NewWithForcedVersion("https://github.com/pchchv/getqr", 7, Highest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetLogo(&Logo{Image: testLogo(10, 10, red)}); err != nil {
+		t.Fatal(err)
+	}
+	// The centre alignment pattern of version 7 is at (22, 22)
+	bits := q.Bitmap()
+	qz := q.quietZoneSize()
+	for y := -2; y <= 2; y++ {
+		for x := -2; x <= 2; x++ {
+			want := x == -2 || x == 2 || y == -2 || y == 2 || (x == 0 && y == 0)
+			if bits[22+y+qz][22+x+qz] != want {
+				t.Fatalf("alignment module (%d, %d) got %v, want %v", 22+x, 22+y, !want, want)
+			}
+		}
+	}
+	img := q.Image(-4)
+	for _, m := range []int{20, 22} {
+		p := 4*(m+qz) + 2
+		if got := img.At(p, p); !sameColor(got, q.ForegroundColor) {
+			t.Errorf("alignment module (%d, %d) drawn as %v, want the foreground colour", m, m, got)
+		}
+	}
+	svg, err := q.SVG(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(svg), `clip-path="url(#`+svgLogoClipID+`)"`) {
+		t.Error("SVG logo is not clipped to the alignment pattern")
+	}
+}
+
+func TestMisdecodeCodewords(t *testing.T) {
+	tests := []struct {
+		version int
+		level   RecoveryLevel
+		want    int
+	}{
+		{1, Low, 3},
+		{1, Medium, 2},
+		{1, High, 1},
+		{1, Highest, 1},
+		{2, Low, 2},
+		{2, Medium, 0},
+		{3, Low, 1},
+		{3, Medium, 0},
+		{4, Low, 0},
+	}
+	for _, test := range tests {
+		if got := misdecodeCodewords(*getQRCodeVersion(test.level, test.version)); got != test.want {
+			t.Errorf("version %d level %d: got %d, want %d", test.version, test.level, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package getqr
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// ModuleRole is the part of a symbol a module belongs to
+type ModuleRole uint8
+
+const (
+	RoleData      ModuleRole = iota // Data and error correction codewords, and remainder bits
+	RoleFinder                      // Finder patterns and their separators
+	RoleAlignment                   // Alignment patterns
+	RoleTiming                      // Timing patterns
+	RoleVersion                     // Version information
+	RoleFormat                      // Format information, including the always dark module
+)
+
+func (r ModuleRole) String() string {
+	switch r {
+	case RoleData:
+		return "data"
+	case RoleFinder:
+		return "finder"
+	case RoleAlignment:
+		return "alignment"
+	case RoleTiming:
+		return "timing"
+	case RoleVersion:
+		return "version"
+	case RoleFormat:
+		return "format"
+	}
+	return fmt.Sprintf("ModuleRole(%d)", int(r))
+}
+
+// ModuleColorFunc returns the colour of the module at (x, y), in modules from the top left corner of the symbol,
+// excluding the quiet zone. dark reports whether the module is dark, and role is the part of the symbol it belongs to
+// A nil colour leaves the module unpainted, showing the background
+type ModuleColorFunc func(x, y int, dark bool, role ModuleRole) color.Color
+
+// moduleRun is a run of n modules in a row, starting at (x, y)
+type moduleRun struct {
+	x, y, n int
+}
+
+// moduleColorGroup is the runs of modules painted in one colour
+type moduleColorGroup struct {
+	color color.Color
+	runs  []moduleRun
+}
+
+// Returns the colour of each module of the symbol given by the ModuleColor function, indexed by y*symbolSize+x
+// The QR Code must be encoded
+func (q *QRCode) moduleColors() []color.Color {
+	s := q.symbol
+	size := s.symbolSize
+	roles := buildModuleRoles(q.version)
+	colors := make([]color.Color, size*size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			colors[y*size+x] = q.ModuleColor(x, y, s.get(x, y), roles[y*size+x])
+		}
+	}
+	return colors
+}
+
+// Returns the painted modules, as runs of the same colour grouped by colour. Positions exclude the quiet zone
+// The QR Code must be encoded
+func (q *QRCode) moduleColorGroups() []moduleColorGroup {
+	size := q.symbol.symbolSize
+	colors := q.moduleColors()
+	var groups []moduleColorGroup
+	index := make(map[color.NRGBA]int)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; {
+			c := colors[y*size+x]
+			if c == nil {
+				x++
+				continue
+			}
+			key := color.NRGBAModel.Convert(c).(color.NRGBA)
+			n := 1
+			for x+n < size && colors[y*size+x+n] != nil && color.NRGBAModel.Convert(colors[y*size+x+n]) == key {
+				n++
+			}
+			i, ok := index[key]
+			if !ok {
+				i = len(groups)
+				index[key] = i
+				groups = append(groups, moduleColorGroup{color: c})
+			}
+			groups[i].runs = append(groups[i].runs, moduleRun{x, y, n})
+			x += n
+		}
+	}
+	return groups
+}
+
+// Returns the bytes of the colour of each module of the encoded QR Code, given by the ModuleColor function,
+// in the format of an image given by pixel
+func (q *QRCode) moduleColorValues(pixel func(color.Color) [4]byte) [][4]byte {
+	bg := pixel(q.BackgroundColor)
+	colors := q.moduleColors()
+	values := make([][4]byte, len(colors))
+	for i, c := range colors {
+		if c == nil {
+			values[i] = bg
+		} else {
+			values[i] = pixel(c)
+		}
+	}
+	return values
+}
+
+// Draws the pixel rows y0 to y1-1 of the encoded QR Code with layout l, its modules in the colours values
+// from moduleColorValues() and the quiet zone in bg, into the 4 byte per pixel image pix with row stride stride,
+// which holds only those rows
+func (q *QRCode) drawModuleColors(l ImageLayout, values [][4]byte, bg [4]byte, pix []byte, stride, y0, y1 int) {
+	size := q.symbol.symbolSize
+	qz := q.symbol.quietZoneSize
+	for y := y0; y < y1; y++ {
+		my := l.module(y) - qz
+		row := pix[(y-y0)*stride : (y-y0)*stride+4*l.Size]
+		for x := 0; x < l.Size; x++ {
+			mx := l.module(x) - qz
+			v := bg
+			if mx >= 0 && my >= 0 && mx < size && my < size {
+				v = values[my*size+mx]
+			}
+			copy(row[4*x:], v[:])
+		}
+	}
+}
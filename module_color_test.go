@@ -0,0 +1,87 @@
+package getqr
+
+import (
+	"encoding/xml"
+	"image/color"
+	"testing"
+)
+
+func TestModuleColor(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleColors := map[ModuleRole]color.NRGBA{
+		RoleData:      {0, 0, 0, 0xff},
+		RoleFinder:    {0xff, 0, 0, 0xff},
+		RoleAlignment: {0, 0xff, 0, 0xff},
+		RoleTiming:    {0, 0, 0xff, 0xff},
+		RoleFormat:    {0xff, 0, 0xff, 0xff},
+	}
+	calls := 0
+	q.ModuleColor = func(x, y int, dark bool, role ModuleRole) color.Color {
+		calls++
+		if !dark {
+			return nil
+		}
+		return roleColors[role]
+	}
+	const scale = 4
+	img, err := q.NRGBAImage(-scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := q.version.symbolSize()
+	if calls != size*size {
+		t.Errorf("got %d calls, want one per module (%d)", calls, size*size)
+	}
+	roles := buildModuleRoles(q.version)
+	bitmap := q.Bitmap()
+	qz := q.QuietZone
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			want := color.NRGBA{0xff, 0xff, 0xff, 0xff}
+			if bitmap[y+qz][x+qz] {
+				want = roleColors[roles[y*size+x]]
+			}
+			if got := img.NRGBAAt((x+qz)*scale, (y+qz)*scale); got != want {
+				t.Fatalf("module (%d, %d) with role %v: got %v, want %v", x, y, roles[y*size+x], got, want)
+			}
+		}
+	}
+	b, err := q.SVG(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc svgDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	// Version 3 has no version information
+	if len(doc.Paths) != 5 {
+		t.Errorf("got %d paths, want one per colour (5)", len(doc.Paths))
+	}
+	painted := 0
+	for _, p := range doc.Paths {
+		for _, polygon := range parseSVGPath(t, p.D) {
+			painted += (polygon[1].X - polygon[0].X)
+		}
+	}
+	dark := 0
+	for _, row := range bitmap {
+		for _, v := range row {
+			if v {
+				dark++
+			}
+		}
+	}
+	if painted != dark {
+		t.Errorf("SVG paints %d modules, want %d", painted, dark)
+	}
+	if _, err := q.PDF(&PDFOptions{ModuleSize: 1}); err != nil {
+		t.Error(err)
+	}
+	if _, err := q.EPS(nil); err != nil {
+		t.Error(err)
+	}
+}
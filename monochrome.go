@@ -0,0 +1,166 @@
+package getqr
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// Default name of the variables of an XBM image
+const defaultXBMName = "qrcode"
+
+// Returns the rows of the QR Code drawn with layout l, packed 8 pixels per byte, with the bits of dark pixels set
+// Pixels are packed from the most significant bit of each byte, or from the least if lsbFirst is set
+func packedRows(s *symbol, l ImageLayout, lsbFirst bool) [][]byte {
+	rows := make([][]byte, l.Size)
+	stride := (l.Size + 7) / 8
+	for y := range rows {
+		my := l.module(y)
+		// Consecutive pixel rows usually map to the same module row
+		if y > 0 && l.module(y-1) == my {
+			rows[y] = rows[y-1]
+			continue
+		}
+		row := make([]byte, stride)
+		for x := 0; x < l.Size; x++ {
+			if !s.bitmapAt(l.module(x), my) {
+				continue
+			}
+			if lsbFirst {
+				row[x/8] |= 1 << uint(x%8)
+			} else {
+				row[x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+		rows[y] = row
+	}
+	return rows
+}
+
+// Writes the QR Code to out as a Netpbm bitmap, PBM, in the plain (P1) format if plain is set, or else the raw (P4) format
+// A bitmap has no colours: dark modules are black and light modules white. Style, Fill and a logo are not drawn
+// See the documentation for Image() for the meaning of size
+func (q *QRCode) WritePBM(out io.Writer, size int, plain bool) error {
+	// Build QR code
+	q.encode()
+	l := q.ImageLayout(size)
+	rows := packedRows(q.symbol, l, false)
+	w := bufio.NewWriter(out)
+	if !plain {
+		fmt.Fprintf(w, "P4\n%d %d\n", l.Size, l.Size)
+		for _, row := range rows {
+			w.Write(row)
+		}
+		return w.Flush()
+	}
+	fmt.Fprintf(w, "P1\n%d %d\n", l.Size, l.Size)
+	for _, row := range rows {
+		// Lines should be at most 70 characters long
+		for x := 0; x < l.Size; x++ {
+			if x > 0 && x%70 == 0 {
+				w.WriteByte('\n')
+			}
+			w.WriteByte('0' + row[x/8]>>uint(7-x%8)&1)
+		}
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+// Writes the QR Code to out as an X BitMap, XBM, which is C source defining name_width, name_height and name_bits
+// An empty name means "qrcode". Set bits are dark modules. Style, Fill and a logo are not drawn
+// See the documentation for Image() for the meaning of size
+func (q *QRCode) WriteXBM(out io.Writer, size int, name string) error {
+	if name == "" {
+		name = defaultXBMName
+	} else if !isCIdentifier(name) {
+		return fmt.Errorf("invalid XBM name %q (expected a C identifier)", name)
+	}
+	// Build QR code
+	q.encode()
+	l := q.ImageLayout(size)
+	rows := packedRows(q.symbol, l, true)
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "#define %s_width %d\n#define %s_height %d\n", name, l.Size, name, l.Size)
+	fmt.Fprintf(w, "static unsigned char %s_bits[] = {", name)
+	n := 0
+	for _, row := range rows {
+		for _, b := range row {
+			if n > 0 {
+				w.WriteByte(',')
+			}
+			if n%12 == 0 {
+				w.WriteString("\n  ")
+			} else {
+				w.WriteByte(' ')
+			}
+			fmt.Fprintf(w, "0x%02x", b)
+			n++
+		}
+	}
+	w.WriteString("\n};\n")
+	return w.Flush()
+}
+
+// Reports whether name is a valid C identifier
+func isCIdentifier(name string) bool {
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
+
+// Writes the QR Code to out as a 1 bit per pixel Windows bitmap, BMP
+// Its palette is BackgroundColor and ForegroundColor, with transparent colours shown on white. Style, Fill and a logo are not drawn
+// See the documentation for Image() for the meaning of size
+func (q *QRCode) WriteBMP(out io.Writer, size int) error {
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	// Build QR code
+	q.encode()
+	l := q.ImageLayout(size)
+	rows := packedRows(q.symbol, l, false)
+	// Rows are padded to a multiple of 4 bytes
+	stride := (len(rows[0]) + 3) &^ 3
+	const headerSize = 14 + 40 + 2*4
+	w := bufio.NewWriter(out)
+	le := binary.LittleEndian
+	header := make([]byte, headerSize)
+	// File header
+	copy(header, "BM")
+	le.PutUint32(header[2:], uint32(headerSize+stride*l.Size))
+	le.PutUint32(header[10:], headerSize)
+	// BITMAPINFOHEADER
+	info := header[14:]
+	le.PutUint32(info[0:], 40)
+	le.PutUint32(info[4:], uint32(l.Size))
+	le.PutUint32(info[8:], uint32(l.Size))
+	le.PutUint16(info[12:], 1)
+	le.PutUint16(info[14:], 1)
+	le.PutUint32(info[20:], uint32(stride*l.Size))
+	// 2835 pixels per metre, i.e. 72 DPI
+	le.PutUint32(info[24:], 2835)
+	le.PutUint32(info[28:], 2835)
+	le.PutUint32(info[32:], 2)
+	// Palette, as blue, green, red and a reserved byte
+	for i, c := range []color.Color{q.BackgroundColor, q.ForegroundColor} {
+		rgb := compositeOver(c, color.White)
+		copy(header[54+4*i:], []byte{rgb.B, rgb.G, rgb.R, 0})
+	}
+	w.Write(header)
+	padded := make([]byte, stride)
+	// Rows are stored bottom up
+	for y := l.Size - 1; y >= 0; y-- {
+		copy(padded, rows[y])
+		w.Write(padded)
+	}
+	return w.Flush()
+}
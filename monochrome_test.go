@@ -0,0 +1,166 @@
+package getqr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Returns the QR Code drawn by Image() as rows of bools, true where dark
+func testMonochromeRows(q *QRCode, size int) [][]bool {
+	img := q.Image(size).(*image.Paletted)
+	rows := make([][]bool, img.Rect.Dy())
+	for y := range rows {
+		rows[y] = make([]bool, img.Rect.Dx())
+		for x := range rows[y] {
+			rows[y][x] = img.ColorIndexAt(x, y) == 1
+		}
+	}
+	return rows
+}
+
+func TestWritePBM(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not a multiple of 8 pixels wide, and wider than a plain line
+	const size = 100
+	want := testMonochromeRows(q, size)
+	for _, plain := range []bool{true, false} {
+		var b bytes.Buffer
+		if err := q.WritePBM(&b, size, plain); err != nil {
+			t.Fatal(err)
+		}
+		r := bufio.NewReader(&b)
+		var magic string
+		var width, height int
+		if _, err := fmt.Fscanf(r, "%s\n%d %d\n", &magic, &width, &height); err != nil {
+			t.Fatal(err)
+		}
+		if (magic == "P1") != plain || width != size || height != size {
+			t.Fatalf("plain %v: got header %s %d %d", plain, magic, width, height)
+		}
+		got := make([][]bool, height)
+		var pixels bytes.Buffer
+		pixels.ReadFrom(r)
+		rest := pixels.Bytes()
+		if plain {
+			for _, line := range strings.Split(string(rest), "\n") {
+				if len(line) > 70 {
+					t.Errorf("got a line of %d characters, want at most 70", len(line))
+				}
+			}
+			digits := strings.Join(strings.Fields(string(rest)), "")
+			if len(digits) != width*height {
+				t.Fatalf("got %d pixels, want %d", len(digits), width*height)
+			}
+			for y := range got {
+				for x := 0; x < width; x++ {
+					got[y] = append(got[y], digits[y*width+x] == '1')
+				}
+			}
+		} else {
+			stride := (width + 7) / 8
+			if len(rest) != stride*height {
+				t.Fatalf("got %d bytes of pixels, want %d", len(rest), stride*height)
+			}
+			for y := range got {
+				for x := 0; x < width; x++ {
+					got[y] = append(got[y], rest[y*stride+x/8]&(0x80>>uint(x%8)) != 0)
+				}
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("plain %v: pixels differ from Image()", plain)
+		}
+	}
+}
+
+func TestWriteXBM(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const size = 100
+	var b bytes.Buffer
+	if err := q.WriteXBM(&b, size, "label_1"); err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`(?s)^#define label_1_width (\d+)\n#define label_1_height (\d+)\nstatic unsigned char label_1_bits\[\] = \{(.*)\n\};\n$`).
+		FindStringSubmatch(b.String())
+	if m == nil {
+		t.Fatalf("unexpected XBM %q", b.String())
+	}
+	if m[1] != "100" || m[2] != "100" {
+		t.Errorf("got size %sx%s, want 100x100", m[1], m[2])
+	}
+	var data []byte
+	for _, v := range strings.Split(m[3], ",") {
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 0, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, byte(n))
+	}
+	want := testMonochromeRows(q, size)
+	stride := (size + 7) / 8
+	if len(data) != stride*size {
+		t.Fatalf("got %d bytes, want %d", len(data), stride*size)
+	}
+	for y := range want {
+		for x := range want[y] {
+			if got := data[y*stride+x/8]&(1<<uint(x%8)) != 0; got != want[y][x] {
+				t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, got, want[y][x])
+			}
+		}
+	}
+	for _, name := range []string{"1abc", "a-b", "é"} {
+		if err := q.WriteXBM(&b, size, name); err == nil {
+			t.Errorf("name %q: expected an error", name)
+		}
+	}
+}
+
+func TestWriteBMP(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ForegroundColor = color.NRGBA{0x20, 0x40, 0x80, 0xff}
+	const size = 100
+	var b bytes.Buffer
+	if err := q.WriteBMP(&b, size); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	le := binary.LittleEndian
+	if string(data[:2]) != "BM" || int(le.Uint32(data[2:])) != len(data) {
+		t.Fatalf("invalid file header % x", data[:14])
+	}
+	offset := le.Uint32(data[10:])
+	width, height := int32(le.Uint32(data[18:])), int32(le.Uint32(data[22:]))
+	if width != size || height != size || le.Uint16(data[28:]) != 1 || le.Uint32(data[46:]) != 2 {
+		t.Fatalf("invalid info header % x", data[14:54])
+	}
+	if !bytes.Equal(data[54:62], []byte{0xff, 0xff, 0xff, 0, 0x80, 0x40, 0x20, 0}) {
+		t.Errorf("got palette % x, want white and the foreground colour", data[54:62])
+	}
+	stride := 16
+	want := testMonochromeRows(q, size)
+	for y := range want {
+		row := data[int(offset)+(size-1-y)*stride:]
+		for x := range want[y] {
+			if got := row[x/8]&(0x80>>uint(x%8)) != 0; got != want[y][x] {
+				t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, got, want[y][x])
+			}
+		}
+	}
+}
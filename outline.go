@@ -0,0 +1,87 @@
+package getqr
+
+import "image"
+
+// Directions of the outline edges, clockwise starting from +x. The y axis points down
+const (
+	edgeRight = iota
+	edgeDown
+	edgeLeft
+	edgeUp
+)
+
+var edgeDelta = [4]image.Point{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// Calls fn with each closed outline of the set modules of the symbol, as a list of corner points in module coordinates
+// (excluding the quiet zone). The outline is closed implicitly from the last point to the first
+// Outer edges run clockwise and the edges of holes anticlockwise, so the shapes are filled correctly with the nonzero rule
+// The points slice is reused between calls to fn
+func (m *symbol) outline(fn func(points []image.Point)) {
+	n := m.symbolSize + 1 // Number of vertices per row and column
+	// Outgoing edges from each vertex, as a bitmask of directions. Each set module contributes an edge
+	// for each side bordering an unset module, with the module on the right of the edge
+	edges := make([]uint8, n*n)
+	for y := 0; y < m.symbolSize; y++ {
+		for x := 0; x < m.symbolSize; x++ {
+			if !m.get(x, y) {
+				continue
+			}
+			if y == 0 || !m.get(x, y-1) {
+				edges[y*n+x] |= 1 << edgeRight
+			}
+			if x == m.symbolSize-1 || !m.get(x+1, y) {
+				edges[y*n+x+1] |= 1 << edgeDown
+			}
+			if y == m.symbolSize-1 || !m.get(x, y+1) {
+				edges[(y+1)*n+x+1] |= 1 << edgeLeft
+			}
+			if x == 0 || !m.get(x-1, y) {
+				edges[(y+1)*n+x] |= 1 << edgeUp
+			}
+		}
+	}
+	var points []image.Point
+	for start := range edges {
+		// The start vertex may have a second outline passing through it
+		for edges[start] != 0 {
+			points = points[:0]
+			p := image.Point{start % n, start / n}
+			first := -1
+			dir := -1
+			for {
+				v := p.Y*n + p.X
+				next := -1
+				if dir < 0 {
+					for d := edgeRight; d <= edgeUp && next < 0; d++ {
+						if edges[v]&(1<<uint(d)) != 0 {
+							next = d
+						}
+					}
+					first = next
+				} else {
+					// Prefer turning right, so modules touching only at a corner are outlined separately
+					for _, d := range [3]int{(dir + 1) % 4, dir, (dir + 3) % 4} {
+						if edges[v]&(1<<uint(d)) != 0 {
+							next = d
+							break
+						}
+					}
+				}
+				if next != dir {
+					points = append(points, p)
+				}
+				edges[v] &^= 1 << uint(next)
+				dir = next
+				p = p.Add(edgeDelta[dir])
+				if p.Y*n+p.X == start {
+					break
+				}
+			}
+			// Drop the start point if the outline passes straight through it
+			if dir == first {
+				points = points[1:]
+			}
+			fn(points)
+		}
+	}
+}
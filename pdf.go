@@ -0,0 +1,332 @@
+package getqr
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+)
+
+// Points per millimetre. PDF user space units are points, 1/72 inch
+const pointsPerMM = 72 / 25.4
+
+// Length of crop marks, and their distance from the bleed edge, in mm
+const (
+	cropMarkLength = 5
+	cropMarkOffset = 2
+)
+
+// PDFOptions configures the PDF output of a single QR Code. Sizes are in millimetres
+// Exactly one of ModuleSize and Size must be set
+type PDFOptions struct {
+	ModuleSize float64 // Width and height of a module
+	Size       float64 // Width and height of the symbol, including the quiet zone
+	Bleed      float64 // Width of the background extending beyond the symbol on each side, to allow for trimming
+	CropMarks  bool    // Draw crop marks at the corners of the symbol, outside the bleed
+	PageWidth  float64 // Page width. Zero fits the page to the symbol, bleed and crop marks
+	PageHeight float64 // Page height. Zero fits the page to the symbol, bleed and crop marks
+}
+
+// PDFPlacement positions a QR Code on a PDF page. Sizes and positions are in millimetres
+// Exactly one of ModuleSize and Size must be set
+type PDFPlacement struct {
+	QRCode     *QRCode
+	X          float64 // Distance of the left edge of the symbol, including the quiet zone, from the left edge of the page
+	Y          float64 // Distance of the top edge of the symbol, including the quiet zone, from the top edge of the page
+	ModuleSize float64 // Width and height of a module
+	Size       float64 // Width and height of the symbol, including the quiet zone
+	Bleed      float64 // Width of the background extending beyond the symbol on each side, to allow for trimming
+	CropMarks  bool    // Draw crop marks at the corners of the symbol, outside the bleed
+}
+
+// PDFPage is a page of a PDF document holding any number of QR Codes. Sizes are in millimetres
+type PDFPage struct {
+	Width      float64
+	Height     float64
+	Placements []PDFPlacement
+}
+
+// Returns the QR Code as a single page PDF document
+// The symbol is drawn as filled rectangles at the exact physical size requested by opts
+// The quiet zone is included in the symbol size, QuietZone modules wide, as for Image()
+// A fully transparent BackgroundColor leaves the background unpainted. If Style is set, the modules are drawn in the style
+func (q *QRCode) PDF(opts *PDFOptions) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WritePDF(&b, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code as a single page PDF document to out. See the documentation for PDF()
+// Unless a page size is given, the page is fitted to the symbol, bleed and crop marks
+// Otherwise the symbol is centred on the page, and an error is returned if the page is smaller than the symbol, bleed and crop marks
+func (q *QRCode) WritePDF(out io.Writer, opts *PDFOptions) error {
+	if opts == nil {
+		return errors.New("PDF options are required to set the physical size")
+	}
+	p := PDFPlacement{
+		QRCode:     q,
+		ModuleSize: opts.ModuleSize,
+		Size:       opts.Size,
+		Bleed:      opts.Bleed,
+		CropMarks:  opts.CropMarks,
+	}
+	size, err := p.size()
+	if err != nil {
+		return err
+	}
+	margin := p.margin()
+	page := PDFPage{Width: opts.PageWidth, Height: opts.PageHeight}
+	if page.Width == 0 {
+		page.Width = size + 2*margin
+	}
+	if page.Height == 0 {
+		page.Height = size + 2*margin
+	}
+	p.X = (page.Width - size) / 2
+	p.Y = (page.Height - size) / 2
+	page.Placements = []PDFPlacement{p}
+	return WritePDF(out, page)
+}
+
+// Writes a PDF document to out, with a page for each of pages
+// Pages holding a single QR Code get a TrimBox around the symbol and a BleedBox around its bleed
+// An error is returned if a symbol, including its quiet zone, bleed and crop marks, does not fit on its page
+func WritePDF(out io.Writer, pages ...PDFPage) error {
+	if len(pages) == 0 {
+		return errors.New("PDF document has no pages")
+	}
+	w := &pdfWriter{w: bufio.NewWriter(out)}
+	w.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	// Objects 1 and 2 are the catalog and the page tree. Each page is followed by its content stream
+	w.beginObject()
+	w.printf("<< /Type /Catalog /Pages 2 0 R >>\n")
+	w.endObject()
+	w.beginObject()
+	w.printf("<< /Type /Pages /Kids [")
+	for i := range pages {
+		w.printf(" %d 0 R", 3+2*i)
+	}
+	w.printf(" ] /Count %d >>\n", len(pages))
+	w.endObject()
+	for i, page := range pages {
+		if page.Width <= 0 || page.Height <= 0 {
+			return fmt.Errorf("invalid PDF page size %vx%vmm", page.Width, page.Height)
+		}
+		content, err := page.content()
+		if err != nil {
+			return err
+		}
+		width, height := page.Width*pointsPerMM, page.Height*pointsPerMM
+		w.beginObject()
+		w.printf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s]", formatNumber(width), formatNumber(height))
+		if len(page.Placements) == 1 {
+			p := page.Placements[0]
+			size, _ := p.size()
+			w.printf(" /TrimBox %s /BleedBox %s",
+				page.box(p.X, p.Y, size, 0), page.box(p.X, p.Y, size, p.Bleed))
+		}
+		w.printf(" /Resources << >> /Contents %d 0 R >>\n", 4+2*i)
+		w.endObject()
+		w.beginObject()
+		w.printf("<< /Length %d /Filter /FlateDecode >>\nstream\n", len(content))
+		w.write(content)
+		w.printf("\nendstream\n")
+		w.endObject()
+	}
+	xref := w.offset
+	w.printf("xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		w.printf("%010d 00000 n \n", offset)
+	}
+	w.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, xref)
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// Returns the width and height of the symbol in mm, including the quiet zone
+func (p PDFPlacement) size() (float64, error) {
+	if p.QRCode == nil {
+		return 0, errors.New("PDF placement has no QR Code")
+	}
+	switch {
+	case p.ModuleSize > 0 && p.Size == 0:
+		return p.ModuleSize * float64(p.QRCode.bitmapSize()), nil
+	case p.Size > 0 && p.ModuleSize == 0:
+		return p.Size, nil
+	}
+	return 0, fmt.Errorf("invalid PDF symbol size: exactly one of module size (%v) and size (%v) must be positive", p.ModuleSize, p.Size)
+}
+
+// Returns the width of the bleed and crop marks around the symbol in mm
+func (p PDFPlacement) margin() float64 {
+	margin := p.Bleed
+	if p.CropMarks {
+		margin += cropMarkOffset + cropMarkLength
+	}
+	return margin
+}
+
+// Returns a PDF rectangle around the square at (x, y) with width size, grown by margin on each side
+// Positions are in mm from the top left corner of the page
+func (page PDFPage) box(x, y, size, margin float64) string {
+	left := (x - margin) * pointsPerMM
+	bottom := (page.Height - y - size - margin) * pointsPerMM
+	return fmt.Sprintf("[%s %s %s %s]", formatNumber(left), formatNumber(bottom),
+		formatNumber(left+(size+2*margin)*pointsPerMM), formatNumber(bottom+(size+2*margin)*pointsPerMM))
+}
+
+// Returns the compressed content stream drawing the page
+func (page PDFPage) content() ([]byte, error) {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	w := bufio.NewWriter(zw)
+	for _, p := range page.Placements {
+		size, err := p.size()
+		if err != nil {
+			return nil, err
+		}
+		if p.Bleed < 0 {
+			return nil, fmt.Errorf("invalid PDF bleed %v", p.Bleed)
+		}
+		// Allowing for rounding in centring the symbol
+		const tolerance = 1e-9
+		if m := p.margin(); p.X-m < -tolerance || p.Y-m < -tolerance ||
+			p.X+size+m > page.Width+tolerance || p.Y+size+m > page.Height+tolerance {
+			return nil, fmt.Errorf("QR Code %vmm wide with a %vmm margin at (%v, %v) does not fit on the %vx%vmm page",
+				size, m, p.X, p.Y, page.Width, page.Height)
+		}
+		q := p.QRCode
+		if err := q.validateContrast(); err != nil {
+			return nil, err
+		}
+		if q.logo != nil {
+			return nil, errors.New("logos are not supported in PDF output")
+		}
+		if q.Fill != nil {
+			return nil, errors.New("fills are not supported in PDF output")
+		}
+		// Build QR code
+		q.encode()
+		s := q.symbol
+		// Top left corner of the symbol in points, with the origin at the bottom left of the page
+		left := p.X * pointsPerMM
+		top := (page.Height - p.Y) * pointsPerMM
+		sizePt := size * pointsPerMM
+		bleed := p.Bleed * pointsPerMM
+		if _, _, _, a := q.BackgroundColor.RGBA(); a != 0 {
+			fmt.Fprintf(w, "%s rg\n%s %s %s %s re f\n", pdfColor(q.BackgroundColor),
+				formatNumber(left-bleed), formatNumber(top-sizePt-bleed), formatNumber(sizePt+2*bleed), formatNumber(sizePt+2*bleed))
+		}
+		// Draw the modules in module units, with the y axis pointing down from the top left corner of the symbol
+		scale := sizePt / float64(s.size)
+		if q.ModuleColor != nil {
+			fmt.Fprintf(w, "q\n%s 0 0 %s %s %s cm\n", formatNumber(scale), formatNumber(-scale), formatNumber(left), formatNumber(top))
+			for _, g := range q.moduleColorGroups() {
+				fmt.Fprintf(w, "%s rg\n", pdfColor(g.color))
+				for _, r := range g.runs {
+					fmt.Fprintf(w, "%d %d %d 1 re\n", r.x+s.quietZoneSize, r.y+s.quietZoneSize, r.n)
+				}
+				w.WriteString("f\n")
+			}
+			w.WriteString("Q\n")
+		} else if q.Style != nil {
+			fmt.Fprintf(w, "q\n%s 0 0 %s %s %s cm\n", formatNumber(scale), formatNumber(-scale), formatNumber(left), formatNumber(top))
+			f := pathFormatter{w: w, dx: float64(s.quietZoneSize), dy: float64(s.quietZoneSize), ops: pdfPathOperators}
+			for _, g := range q.styledGroups() {
+				fmt.Fprintf(w, "%s rg\n", pdfColor(g.color))
+				g.path(&f)
+				w.WriteString("f*\n")
+			}
+			w.WriteString("Q\n")
+		} else {
+			fmt.Fprintf(w, "q\n%s rg\n%s 0 0 %s %s %s cm\n", pdfColor(q.ForegroundColor),
+				formatNumber(scale), formatNumber(-scale), formatNumber(left), formatNumber(top))
+			for y := 0; y < s.symbolSize; y++ {
+				s.forEachRun(y, func(x int, n int) {
+					fmt.Fprintf(w, "%d %d %d 1 re\n", x+s.quietZoneSize, y+s.quietZoneSize, n)
+				})
+			}
+			w.WriteString("f\nQ\n")
+		}
+		if p.CropMarks {
+			writePDFCropMarks(w, left-bleed, top+bleed, sizePt+2*bleed)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Draws crop marks outside the corners of the square with top left corner (left, top) and width size, in points
+func writePDFCropMarks(w io.Writer, left, top, size float64) {
+	offset := cropMarkOffset * pointsPerMM
+	length := cropMarkLength * pointsPerMM
+	fmt.Fprintf(w, "q\n0 G\n0.25 w\n")
+	for _, x := range [2]float64{left, left + size} {
+		for _, y := range [2]float64{top, top - size} {
+			// Direction away from the square
+			dx, dy := -1.0, 1.0
+			if x > left {
+				dx = 1
+			}
+			if y < top {
+				dy = -1
+			}
+			// Horizontal mark, in line with the edge y, and vertical mark, in line with the edge x
+			fmt.Fprintf(w, "%s %s m %s %s l S\n", formatNumber(x+dx*offset), formatNumber(y),
+				formatNumber(x+dx*(offset+length)), formatNumber(y))
+			fmt.Fprintf(w, "%s %s m %s %s l S\n", formatNumber(x), formatNumber(y+dy*offset),
+				formatNumber(x), formatNumber(y+dy*(offset+length)))
+		}
+	}
+	fmt.Fprintf(w, "Q\n")
+}
+
+// Returns c as PDF DeviceRGB components. Alpha is ignored
+func pdfColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return formatNumber(float64(n.R)/0xff) + " " + formatNumber(float64(n.G)/0xff) + " " + formatNumber(float64(n.B)/0xff)
+}
+
+// pdfWriter writes PDF objects, recording their offsets for the cross-reference table
+type pdfWriter struct {
+	w       *bufio.Writer
+	offset  int   // Number of bytes written
+	offsets []int // Offset of each object, the first being object 1
+	err     error
+}
+
+func (w *pdfWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	var n int
+	n, w.err = w.w.Write(b)
+	w.offset += n
+}
+
+func (w *pdfWriter) printf(format string, a ...interface{}) {
+	w.write([]byte(fmt.Sprintf(format, a...)))
+}
+
+// Starts the next object
+func (w *pdfWriter) beginObject() {
+	w.offsets = append(w.offsets, w.offset)
+	w.printf("%d 0 obj\n", len(w.offsets))
+}
+
+func (w *pdfWriter) endObject() {
+	w.printf("endobj\n")
+}
@@ -0,0 +1,136 @@
+package getqr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Checks the cross-reference table of a PDF document and returns its inflated content streams
+func parsePDF(t *testing.T, pdf []byte) [][]byte {
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to the xref table", xref)
+	}
+	for i, offset := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1) {
+		o, _ := strconv.Atoi(string(offset[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[o:], []byte(want)) {
+			t.Fatalf("xref entry %d points to %q, want %q", i+1, pdf[o:o+10], want)
+		}
+	}
+	var streams [][]byte
+	for _, s := range regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllSubmatchIndex(pdf, -1) {
+		length, _ := strconv.Atoi(string(pdf[s[2]:s[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(pdf[s[1] : s[1]+length]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		streams = append(streams, content)
+	}
+	return streams
+}
+
+// Returns the total area of the module rectangles in a content stream
+func pdfModuleArea(content []byte) int {
+	area := 0
+	for _, m := range regexp.MustCompile(`(?m)^\d+ \d+ (\d+) 1 re$`).FindAllSubmatch(content, -1) {
+		n, _ := strconv.Atoi(string(m[1]))
+		area += n
+	}
+	return area
+}
+
+func TestPDF(t *testing.T) {
+	q, err := New("getqr", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf, err := q.PDF(&PDFOptions{ModuleSize: 0.5, Bleed: 1, CropMarks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	streams := parsePDF(t, pdf)
+	if len(streams) != 1 {
+		t.Fatalf("got %d content streams, want 1", len(streams))
+	}
+	dark := 0
+	for _, row := range q.Bitmap() {
+		for _, v := range row {
+			if v {
+				dark++
+			}
+		}
+	}
+	if area := pdfModuleArea(streams[0]); area != dark {
+		t.Errorf("modules cover %d, want %d", area, dark)
+	}
+	// 29 modules of 0.5mm, 1mm bleed and 7mm crop mark margin on each side: 30.5mm = 86.4567pt
+	if !bytes.Contains(pdf, []byte("/MediaBox [0 0 86.4567 86.4567]")) {
+		t.Errorf("missing expected MediaBox in %s", pdf)
+	}
+	// The symbol is 14.5mm = 41.1024pt, 8mm = 22.6772pt from each edge
+	if !bytes.Contains(pdf, []byte("/TrimBox [22.6772 22.6772 63.7795 63.7795]")) {
+		t.Error("missing expected TrimBox")
+	}
+	if got := strings.Count(string(streams[0]), " l S\n"); got != 8 {
+		t.Errorf("got %d crop mark lines, want 8", got)
+	}
+}
+
+func TestWritePDFPages(t *testing.T) {
+	var placements []PDFPlacement
+	for i, content := range []string{"a", "b", "c"} {
+		q, err := New(content, Medium)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placements = append(placements, PDFPlacement{QRCode: q, X: 10 + 60*float64(i), Y: 10, Size: 50})
+	}
+	var b bytes.Buffer
+	err := WritePDF(&b, PDFPage{Width: 210, Height: 297, Placements: placements}, PDFPage{Width: 100, Height: 100, Placements: placements[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streams := parsePDF(t, b.Bytes()); len(streams) != 2 {
+		t.Errorf("got %d content streams, want 2", len(streams))
+	}
+	if err := WritePDF(&b, PDFPage{Width: 100, Height: 100, Placements: []PDFPlacement{{QRCode: placements[0].QRCode, ModuleSize: 1, Size: 20}}}); err == nil {
+		t.Error("WritePDF with both module size and size succeeded, want error")
+	}
+	if _, err := placements[0].QRCode.PDF(nil); err == nil {
+		t.Error("PDF without options succeeded, want error")
+	}
+	if _, err := placements[0].QRCode.PDF(&PDFOptions{Size: 50, PageWidth: 40, PageHeight: 100}); err == nil {
+		t.Error("PDF with a page narrower than the symbol succeeded, want error")
+	}
+	if err := WritePDF(&b, PDFPage{Width: 100, Height: 100, Placements: placements[1:2]}); err == nil {
+		t.Error("WritePDF with a QR Code off the page succeeded, want error")
+	}
+	// 10mm from the edges: the symbol fits, but not with 12mm of bleed, or 4mm of bleed and crop marks
+	fits := PDFPlacement{QRCode: placements[0].QRCode, X: 10, Y: 10, Size: 50}
+	if err := WritePDF(&b, PDFPage{Width: 70, Height: 70, Placements: []PDFPlacement{fits}}); err != nil {
+		t.Errorf("WritePDF with a QR Code on the page failed: %v", err)
+	}
+	for _, p := range []PDFPlacement{{Bleed: 12}, {Bleed: 4, CropMarks: true}, {Bleed: -1}} {
+		p.QRCode, p.X, p.Y, p.Size = fits.QRCode, fits.X, fits.Y, fits.Size
+		if err := WritePDF(&b, PDFPage{Width: 70, Height: 70, Placements: []PDFPlacement{p}}); err == nil {
+			t.Errorf("WritePDF with bleed %v and crop marks %v succeeded, want error", p.Bleed, p.CropMarks)
+		}
+	}
+}
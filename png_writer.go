@@ -0,0 +1,188 @@
+package getqr
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sync"
+)
+
+// The 8 byte PNG file signature
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// Size of the IDAT chunks written, matching image/png
+const pngIDATSize = 1 << 15
+
+// pngWriter streams a symbol as a 1-bit paletted PNG image, without building an image.Image
+// Only a single pixel row is held in memory, so the memory used does not depend on the image height
+// The compressor and buffers are kept between images, see pngWriters
+type pngWriter struct {
+	out    io.Writer
+	err    error
+	zw     *zlib.Writer
+	idat   *bufio.Writer // Splits the compressed data into IDAT chunks
+	row    []byte        // Filter type byte followed by the packed pixels of one row
+	header [8]byte
+	footer [4]byte
+	tmp    [13]byte
+}
+
+// Idle pngWriters, shared by all PNG output
+var pngWriters = sync.Pool{
+	New: func() interface{} { return &pngWriter{} },
+}
+
+// pngBufferPool keeps the buffers of image/png between images, for the truecolor images that cannot be streamed
+type pngBufferPool struct {
+	pool sync.Pool
+}
+
+// Shared by all truecolor PNG encoding
+var pngBuffers = &pngBufferPool{}
+
+func (p *pngBufferPool) Get() *png.EncoderBuffer {
+	b, _ := p.pool.Get().(*png.EncoderBuffer)
+	return b
+}
+
+func (p *pngBufferPool) Put(b *png.EncoderBuffer) {
+	p.pool.Put(b)
+}
+
+// Writes img to out as a PNG image, with the compression of writePNG() and buffers from pngBuffers
+func encodePNG(out io.Writer, img image.Image) error {
+	encoder := png.Encoder{CompressionLevel: png.BestCompression, BufferPool: pngBuffers}
+	return encoder.Encode(out, img)
+}
+
+// Writes the symbol to out as a PNG image with palette {background, foreground}
+// The modules are mapped to pixels using l. The output is the same as encoding the image drawn by Image() with image/png
+func writePNG(out io.Writer, s *symbol, l ImageLayout, background, foreground color.Color) error {
+	w := pngWriters.Get().(*pngWriter)
+	defer pngWriters.Put(w)
+	return w.write(out, s, l, background, foreground)
+}
+
+func (w *pngWriter) write(out io.Writer, s *symbol, l ImageLayout, background, foreground color.Color) error {
+	size := l.Size
+	w.out = out
+	w.err = nil
+	defer func() {
+		w.out = nil
+	}()
+	_, w.err = io.WriteString(w.out, pngHeader)
+	// IHDR: width, height, bit depth 1, colour type 3 (palette), default compression, filter and interlace methods
+	binary.BigEndian.PutUint32(w.tmp[0:4], uint32(size))
+	binary.BigEndian.PutUint32(w.tmp[4:8], uint32(size))
+	w.tmp[8] = 1
+	w.tmp[9] = 3
+	w.tmp[10] = 0
+	w.tmp[11] = 0
+	w.tmp[12] = 0
+	w.writeChunk(w.tmp[:13], "IHDR")
+	w.writePalette(background, foreground)
+	if w.err != nil {
+		return w.err
+	}
+	if w.idat == nil {
+		w.idat = bufio.NewWriterSize(w, pngIDATSize)
+	} else {
+		w.idat.Reset(w)
+	}
+	if w.zw == nil {
+		var err error
+		w.zw, err = zlib.NewWriterLevel(w.idat, zlib.BestCompression)
+		if err != nil {
+			return err
+		}
+	} else {
+		w.zw.Reset(w.idat)
+	}
+	rowLen := 1 + (size+7)/8
+	if cap(w.row) < rowLen {
+		w.row = make([]byte, rowLen)
+	}
+	w.row = w.row[:rowLen]
+	// Map each image pixel to its QR code module, as Image() does
+	lastY2 := 0
+	for y := 0; y < size; y++ {
+		y2 := l.module(y)
+		// Consecutive pixel rows usually map to the same module row, and so are identical
+		if y == 0 || y2 != lastY2 {
+			for i := range w.row {
+				w.row[i] = 0 // Including filter type 0, none
+			}
+			// The foreground is palette entry 1, as for Image()
+			for x := 0; x < size; x++ {
+				if s.bitmapAt(l.module(x), y2) {
+					w.row[1+x/8] |= 0x80 >> uint(x%8)
+				}
+			}
+			lastY2 = y2
+		}
+		if _, err := w.zw.Write(w.row); err != nil {
+			return err
+		}
+	}
+	if err := w.zw.Close(); err != nil {
+		return err
+	}
+	if err := w.idat.Flush(); err != nil {
+		return err
+	}
+	w.writeChunk(nil, "IEND")
+	return w.err
+}
+
+// Writes the PLTE chunk, and the tRNS chunk if either colour is not opaque
+func (w *pngWriter) writePalette(background, foreground color.Color) {
+	var alpha [2]byte
+	last := -1
+	for i, c := range [2]color.Color{background, foreground} {
+		c1 := color.NRGBAModel.Convert(c).(color.NRGBA)
+		w.tmp[3*i+0] = c1.R
+		w.tmp[3*i+1] = c1.G
+		w.tmp[3*i+2] = c1.B
+		if c1.A != 0xff {
+			last = i
+		}
+		alpha[i] = c1.A
+	}
+	w.writeChunk(w.tmp[:6], "PLTE")
+	if last != -1 {
+		w.writeChunk(alpha[:last+1], "tRNS")
+	}
+}
+
+// Writes b as an IDAT chunk. This makes pngWriter the destination of w.idat
+func (w *pngWriter) Write(b []byte) (int, error) {
+	w.writeChunk(b, "IDAT")
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(b), nil
+}
+
+// Writes a chunk of type name with data b. Any error is recorded in w.err
+func (w *pngWriter) writeChunk(b []byte, name string) {
+	if w.err != nil {
+		return
+	}
+	binary.BigEndian.PutUint32(w.header[:4], uint32(len(b)))
+	copy(w.header[4:8], name)
+	crc := crc32.Update(0, crc32.IEEETable, w.header[4:8])
+	crc = crc32.Update(crc, crc32.IEEETable, b)
+	binary.BigEndian.PutUint32(w.footer[:4], crc)
+	if _, w.err = w.out.Write(w.header[:8]); w.err != nil {
+		return
+	}
+	if _, w.err = w.out.Write(b); w.err != nil {
+		return
+	}
+	_, w.err = w.out.Write(w.footer[:4])
+}
@@ -0,0 +1,95 @@
+package getqr
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestWritePNGMatchesImage(t *testing.T) {
+	colors := [][2]color.Color{
+		{color.White, color.Black},
+		{color.Transparent, color.RGBA{0x10, 0x20, 0x30, 0xff}},
+		{color.NRGBA{0xff, 0xee, 0xdd, 0x80}, color.NRGBA{0x00, 0x00, 0x40, 0xc0}},
+		{color.White, color.NRGBA{0x20, 0x20, 0x20, 0x40}},
+	}
+	for i, c := range colors {
+		for _, size := range []int{-1, -7, 10, 97, 1000} {
+			q, err := New("https://github.com/pchchv/getqr", Medium)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q.BackgroundColor, q.ForegroundColor = c[0], c[1]
+			q.IntegerScaling = i%2 == 1
+			var want bytes.Buffer
+			encoder := png.Encoder{CompressionLevel: png.BestCompression}
+			if err := encoder.Encode(&want, q.Image(size)); err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			if err := q.Write(size, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Errorf("colours %v size %d: streamed PNG differs from encoded Image()", c, size)
+			}
+		}
+	}
+}
+
+func TestWritePNGSameColors(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []color.Color{color.Black, color.Gray{0x08}} {
+		q.BackgroundColor, q.ForegroundColor = color.Black, c
+		if err := q.Write(-1, &bytes.Buffer{}); err == nil {
+			t.Errorf("foreground %v: expected an error for colours too similar to tell apart", c)
+		}
+	}
+}
+
+type failingWriter struct {
+	remaining int
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	if len(b) > w.remaining {
+		return 0, errors.New("write failed")
+	}
+	w.remaining -= len(b)
+	return len(b), nil
+}
+
+func TestWritePNGError(t *testing.T) {
+	q, err := New("getqr", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 20, 60, 200} {
+		if err := q.Write(-10, &failingWriter{remaining: n}); err == nil {
+			t.Errorf("Write to a writer failing after %d bytes succeeded, want error", n)
+		}
+	}
+}
+
+func TestPNGBufferPool(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Style = &Style{Modules: ModuleCircle}
+	var first, second bytes.Buffer
+	if err := q.Write(-3, &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Write(-3, &second); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("PNG differs when encoded with pooled buffers")
+	}
+}
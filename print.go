@@ -0,0 +1,171 @@
+package getqr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"strings"
+)
+
+// Resolution of a print PNG when none is given, in dots per inch
+const defaultPrintDPI = 300
+
+// Millimetres per inch
+const mmPerInch = 25.4
+
+// Keyword of the PNG text chunk holding the encoded content
+const pngTextKeyword = "Description"
+
+// PrintOptions sets the physical size of a PNG image, see PrintPNG(). Sizes are in millimetres
+// Exactly one of ModuleSize and Size must be set
+type PrintOptions struct {
+	DPI        float64 // Resolution in dots per inch. Zero means 300
+	ModuleSize float64 // Width and height of a module
+	Size       float64 // Width and height of the symbol, including the quiet zone
+	EmbedText  bool    // Embed the encoded content in a tEXt chunk, or an iTXt chunk if it is not ASCII. Content containing NUL is an error
+}
+
+// Returns the layout of a PNG image of the QR Code at the physical size set by opts, as PrintPNG() draws it
+// Modules are a whole number of pixels, the nearest to the requested size, so the printed size can differ slightly
+// The printed width is l.Size / DPI inches
+func (q *QRCode) PrintLayout(opts *PrintOptions) (ImageLayout, error) {
+	if opts == nil {
+		return ImageLayout{}, errors.New("no print options")
+	}
+	dpi := opts.DPI
+	if dpi == 0 {
+		dpi = defaultPrintDPI
+	} else if dpi < 0 {
+		return ImageLayout{}, fmt.Errorf("invalid DPI %v", dpi)
+	}
+	modules := q.bitmapSize()
+	moduleSize := opts.ModuleSize
+	switch {
+	case (opts.ModuleSize == 0) == (opts.Size == 0):
+		return ImageLayout{}, errors.New("exactly one of ModuleSize and Size must be set")
+	case opts.ModuleSize < 0 || opts.Size < 0:
+		return ImageLayout{}, errors.New("print sizes must be positive")
+	case opts.Size > 0:
+		moduleSize = opts.Size / float64(modules)
+	}
+	pixels := int(math.Round(moduleSize / mmPerInch * dpi))
+	if pixels < 1 {
+		return ImageLayout{}, fmt.Errorf("modules of %.3gmm are smaller than a pixel at %v DPI", moduleSize, dpi)
+	}
+	return newImageLayout(pixels*modules, modules, true), nil
+}
+
+// Returns the QR Code as a PNG image for printing at the physical size set by opts, see PrintLayout()
+// The image records its resolution in a pHYs chunk, so that applications place it at that size
+func (q *QRCode) PrintPNG(opts *PrintOptions) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WritePrintPNG(&b, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code as a PNG image for printing to out. See the documentation for PrintPNG()
+func (q *QRCode) WritePrintPNG(out io.Writer, opts *PrintOptions) error {
+	l, err := q.PrintLayout(opts)
+	if err != nil {
+		return err
+	}
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	dpi := opts.DPI
+	if dpi == 0 {
+		dpi = defaultPrintDPI
+	}
+	// pHYs: pixels per metre along each axis, and the unit, metres
+	var phys [9]byte
+	ppm := uint32(math.Round(dpi / mmPerInch * 1000))
+	binary.BigEndian.PutUint32(phys[0:], ppm)
+	binary.BigEndian.PutUint32(phys[4:], ppm)
+	phys[8] = 1
+	chunks := appendPNGChunk(nil, "pHYs", phys[:])
+	if opts.EmbedText {
+		// PNG text chunks end their keyword with NUL, and cannot hold it
+		if strings.IndexByte(q.Content, 0) >= 0 {
+			return errors.New("content containing NUL cannot be embedded in a PNG text chunk")
+		}
+		chunks = appendPNGText(chunks, pngTextKeyword, q.Content)
+	}
+	w := &pngChunkInserter{out: out, chunks: chunks}
+	// Build QR code
+	q.encode()
+	if q.truecolor() {
+		return encodePNG(w, q.drawNRGBA(l))
+	}
+	return writePNG(w, q.symbol, l, q.BackgroundColor, q.ForegroundColor)
+}
+
+// Appends the PNG chunk of type name with data b to dst
+func appendPNGChunk(dst []byte, name string, b []byte) []byte {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(b)))
+	dst = append(dst, n[:]...)
+	start := len(dst)
+	dst = append(dst, name...)
+	dst = append(dst, b...)
+	binary.BigEndian.PutUint32(n[:], crc32.ChecksumIEEE(dst[start:]))
+	return append(dst, n[:]...)
+}
+
+// Appends a PNG chunk holding text under keyword to dst
+// ASCII text is stored in a tEXt chunk, and other text, as UTF-8, in an uncompressed iTXt chunk. text must not contain NUL
+func appendPNGText(dst []byte, keyword string, text string) []byte {
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	b := append([]byte(keyword), 0)
+	if ascii {
+		return appendPNGChunk(dst, "tEXt", append(b, text...))
+	}
+	// No compression, and no language or translated keyword
+	b = append(b, 0, 0, 0, 0)
+	return appendPNGChunk(dst, "iTXt", append(b, text...))
+}
+
+// pngChunkInserter passes a PNG stream through to out, inserting chunks after the IHDR chunk
+type pngChunkInserter struct {
+	out     io.Writer
+	chunks  []byte // Encoded chunks to insert, or nil once written
+	written int    // Bytes of the stream passed through so far
+}
+
+func (w *pngChunkInserter) Write(b []byte) (int, error) {
+	n := len(b)
+	if w.chunks != nil {
+		// The signature, then the IHDR chunk with its length, type and CRC
+		const headerSize = len(pngHeader) + 4 + 4 + 13 + 4
+		if w.written+len(b) < headerSize {
+			w.written += len(b)
+			_, err := w.out.Write(b)
+			return n, err
+		}
+		head := headerSize - w.written
+		if _, err := w.out.Write(b[:head]); err != nil {
+			return 0, err
+		}
+		if _, err := w.out.Write(w.chunks); err != nil {
+			return 0, err
+		}
+		w.chunks = nil
+		b = b[head:]
+	}
+	if _, err := w.out.Write(b); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
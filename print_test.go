@@ -0,0 +1,121 @@
+package getqr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"testing"
+)
+
+// pngChunk is a chunk of a PNG image
+type pngChunk struct {
+	name string
+	data []byte
+}
+
+// Returns the chunks of the PNG image b
+func readPNGChunks(t *testing.T, b []byte) []pngChunk {
+	if !bytes.HasPrefix(b, []byte(pngHeader)) {
+		t.Fatal("missing PNG signature")
+	}
+	b = b[len(pngHeader):]
+	var chunks []pngChunk
+	for len(b) > 0 {
+		n := int(binary.BigEndian.Uint32(b))
+		chunks = append(chunks, pngChunk{string(b[4:8]), b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return chunks
+}
+
+func TestPrintLayout(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modules := q.bitmapSize()
+	tests := []struct {
+		opts       PrintOptions
+		moduleSize int
+	}{
+		{PrintOptions{Size: 25, DPI: 600}, 16},
+		{PrintOptions{ModuleSize: 0.5, DPI: 600}, 12},
+		// 300 DPI
+		{PrintOptions{ModuleSize: 0.5}, 6},
+	}
+	for _, test := range tests {
+		l, err := q.PrintLayout(&test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.ModuleSize != test.moduleSize || l.Size != test.moduleSize*modules || l.Offset != 0 {
+			t.Errorf("%+v: got layout %+v, want modules of %d pixels", test.opts, l, test.moduleSize)
+		}
+	}
+	for _, opts := range []*PrintOptions{nil, {}, {Size: 25, ModuleSize: 1}, {Size: -1}, {ModuleSize: 0.01}, {Size: 25, DPI: -1}} {
+		if _, err := q.PrintLayout(opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+func TestPrintPNG(t *testing.T) {
+	for _, content := range []string{"https://github.com/pchchv/getqr", "Grüße"} {
+		q, err := New(content, Medium)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, style := range []*Style{nil, {Modules: ModuleCircle}} {
+			q.Style = style
+			b, err := q.PrintPNG(&PrintOptions{ModuleSize: 0.5, DPI: 600, EmbedText: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := q.Image(-12)
+			if img.Bounds() != want.Bounds() {
+				t.Fatalf("got bounds %v, want %v", img.Bounds(), want.Bounds())
+			}
+			for y := 0; y < img.Bounds().Dy(); y += 3 {
+				for x := 0; x < img.Bounds().Dx(); x += 3 {
+					if !sameColor(img.At(x, y), want.At(x, y)) {
+						t.Fatalf("pixel (%d, %d): got %v, want %v", x, y, img.At(x, y), want.At(x, y))
+					}
+				}
+			}
+			chunks := readPNGChunks(t, b)
+			if chunks[0].name != "IHDR" || chunks[1].name != "pHYs" {
+				t.Fatalf("got chunks %s, %s first, want IHDR, pHYs", chunks[0].name, chunks[1].name)
+			}
+			// 600 DPI is 23622 pixels per metre
+			phys := chunks[1].data
+			if binary.BigEndian.Uint32(phys) != 23622 || binary.BigEndian.Uint32(phys[4:]) != 23622 || phys[8] != 1 {
+				t.Errorf("got pHYs % x", phys)
+			}
+			text := chunks[2]
+			wantName, wantData := "tEXt", "Description\x00"+content
+			if content != "https://github.com/pchchv/getqr" {
+				wantName, wantData = "iTXt", "Description\x00\x00\x00\x00\x00"+content
+			}
+			if text.name != wantName || string(text.data) != wantData {
+				t.Errorf("got %s chunk %q, want %s chunk %q", text.name, text.data, wantName, wantData)
+			}
+		}
+	}
+}
+
+func TestPrintPNGNul(t *testing.T) {
+	q, err := New("a\x00b", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.PrintPNG(&PrintOptions{ModuleSize: 0.5, EmbedText: true}); err == nil {
+		t.Error("expected an error embedding content containing NUL")
+	}
+	if _, err := q.PrintPNG(&PrintOptions{ModuleSize: 0.5}); err != nil {
+		t.Errorf("got error %v without embedded text", err)
+	}
+}
@@ -10,11 +10,14 @@ import (
 )
 
 func main() {
-	outFile := flag.String("o", "", "out PNG file prefix, empty for stdout")
+	outFile := flag.String("o", "", "out file prefix, to which the format is added as the extension, empty for stdout")
+	format := flag.String("f", "", "output format: "+strings.Join(getqr.RendererFormats(), ", ")+
+		"\nempty for png, or the best format a terminal supports if stdout is one")
 	size := flag.Int("s", 256, "image size (pixel)")
-	textArt := flag.Bool("t", false, "print as text-art on stdout")
+	textArt := flag.Bool("t", false, "print as text-art, same as -f txt")
 	negative := flag.Bool("i", false, "invert black and white")
-	disableBorder := flag.Bool("d", false, "disable QR Code border")
+	disableBorder := flag.Bool("d", false, "disable QR Code border, same as -q 0")
+	quietZone := flag.Int("q", 4, "QR Code border (quiet zone) width in modules")
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `qrcode -- QR Code encoder in Go
 https://github.com/pchchv/getqr
@@ -29,6 +32,10 @@ Usage:
        qrcode hello word | display
   2. Save to file if "display" not available:
        qrcode "homepage: https://github.com/pchchv/getqr" > out.png
+  3. Without -o or a pipe, the QR code is shown in the terminal, as a sixel
+     or kitty graphics image if the terminal supports one, or else as text.
+  4. Choose another output format with -f:
+       qrcode -f svg -o out "https://github.com/pchchv/getqr"
 `)
 	}
 	flag.Parse()
@@ -45,33 +52,56 @@ Usage:
 	q, err = getqr.New(content, getqr.Highest)
 	checkError(err)
 
+	q.QuietZone = *quietZone
 	if *disableBorder {
-		q.DisableBorder = true
-	}
-
-	if *textArt {
-		art := q.ToString(*negative)
-		fmt.Println(art)
-		return
+		q.QuietZone = 0
 	}
 
 	if *negative {
 		q.ForegroundColor, q.BackgroundColor = q.BackgroundColor, q.ForegroundColor
 	}
 
-	var png []byte
-	png, err = q.PNG(*size)
-	checkError(err)
+	if *textArt {
+		*format = "txt"
+	}
+	if *format == "" {
+		*format = "png"
+		if term := getqr.DetectTerminal(os.Stdout); term.TTY && *outFile == "" {
+			*format = terminalFormat(term)
+		}
+	}
+	if _, ok := getqr.LookupRenderer(*format); !ok {
+		checkError(fmt.Errorf("Error: unknown format %q", *format))
+	}
 
-	if *outFile == "" {
-		os.Stdout.Write(png)
-	} else {
+	out := os.Stdout
+	if *outFile != "" {
+		// Variants of a format, e.g. txt-small, share its extension
+		ext := strings.SplitN(*format, "-", 2)[0]
 		var fh *os.File
-		fh, err = os.Create(*outFile + ".png")
+		fh, err = os.Create(*outFile + "." + ext)
 		checkError(err)
 		defer fh.Close()
-		fh.Write(png)
+		out = fh
+	}
+	checkError(q.Render(out, *format, &getqr.RenderOptions{Size: *size, InverseColor: *negative}))
+	if *format == "sixel" && *outFile == "" {
+		// Move the cursor below the image
+		fmt.Println()
+	}
+}
+
+// Returns the best format to show a QR Code on a terminal with capabilities term
+func terminalFormat(term getqr.TerminalCapabilities) string {
+	switch {
+	case term.Kitty:
+		return "kitty"
+	case term.Sixel:
+		return "sixel"
+	case term.TrueColor:
+		return "ansi"
 	}
+	return "txt-small"
 }
 
 func checkError(err error) {
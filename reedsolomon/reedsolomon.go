@@ -2,6 +2,7 @@ package reedsolomon
 
 import (
 	"log"
+	"sync"
 
 	bitset "github.com/pchchv/getqr/bitset"
 )
@@ -33,6 +34,44 @@ func Encode(data *bitset.Bitset, numECBytes int) *bitset.Bitset {
 	return result
 }
 
+// Generator polynomials by degree, computed on first use by EncodeBytes
+var generatorCache [256]struct {
+	once sync.Once
+	poly gfPoly
+}
+
+// Computes the Reed-Solomon error correction bytes for data and stores them in ec
+// The number of error correction bytes is len(ec). The result is the same as the
+// bytes appended by Encode, but EncodeBytes works on whole codewords and does not
+// allocate, which suits repeated encoding
+func EncodeBytes(data []byte, ec []byte) {
+	numECBytes := len(ec)
+	if numECBytes < 2 || numECBytes > 255 {
+		log.Panicf("numECBytes %d out of range 2-255", numECBytes)
+	}
+	cache := &generatorCache[numECBytes]
+	cache.once.Do(func() {
+		cache.poly = rsGeneratorPoly(numECBytes)
+	})
+	generator := cache.poly.term
+	for i := range ec {
+		ec[i] = 0
+	}
+	// Polynomial long division of data*(x^numECBytes) by the generator polynomial
+	// ec holds the running remainder, highest degree term first
+	for _, d := range data {
+		factor := gfElement(d) ^ gfElement(ec[0])
+		copy(ec, ec[1:])
+		ec[numECBytes-1] = 0
+		if factor == gfZero {
+			continue
+		}
+		for i := range ec {
+			ec[i] ^= byte(gfMultiply(generator[numECBytes-1-i], factor))
+		}
+	}
+}
+
 // Returns the Reed-Solomon generator polynomial with degree
 // The generator polynomial is calculated as:
 // (x + a^0)(x + a^1)...(x + a^degree-1)
@@ -0,0 +1,44 @@
+package reedsolomon
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	bitset "github.com/pchchv/getqr/bitset"
+)
+
+func TestEncodeBytes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, numECBytes := range []int{7, 10, 13, 22, 30} {
+		for _, numDataBytes := range []int{1, 9, 19, 55, 121} {
+			data := make([]byte, numDataBytes)
+			rng.Read(data)
+			data[0] = 0 // Leading zero coefficients must be preserved
+			want := Encode(newBitsetFromBytes(data), numECBytes)
+			ec := make([]byte, numECBytes)
+			EncodeBytes(data, ec)
+			got := newBitsetFromBytes(append(append([]byte{}, data...), ec...))
+			if !got.Equals(want) {
+				t.Errorf("%d data bytes, %d EC bytes: got %s, want %s", numDataBytes, numECBytes, got, want)
+			}
+		}
+	}
+}
+
+func TestEncodeBytesExample(t *testing.T) {
+	// Version 1-M "01234567" example from ISO/IEC 18004 Annex I
+	data := []byte{0x10, 0x20, 0x0c, 0x56, 0x61, 0x80, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+	want := []byte{0xa5, 0x24, 0xd4, 0xc1, 0xed, 0x36, 0xc7, 0x87, 0x2c, 0x55}
+	ec := make([]byte, len(want))
+	EncodeBytes(data, ec)
+	if !bytes.Equal(ec, want) {
+		t.Errorf("got % x, want % x", ec, want)
+	}
+}
+
+func newBitsetFromBytes(data []byte) *bitset.Bitset {
+	b := bitset.New()
+	b.AppendBytes(data)
+	return b
+}
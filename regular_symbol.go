@@ -139,25 +139,29 @@ func (m *regularSymbol) addFormatInfo() {
 	fpSize := finderPatternSize
 	l := formatInfoLengthBits - 1
 	f := m.version.formatInfo(m.mask)
+	// Returns bit i of the format info, counting from the first bit placed
+	at := func(i int) bool {
+		return f&(1<<uint(l-i)) != 0
+	}
 	// Bits 0-7, under the top right finder pattern
 	for i := 0; i <= 7; i++ {
-		m.symbol.set(m.size-i-1, fpSize+1, f.At(l-i))
+		m.symbol.set(m.size-i-1, fpSize+1, at(l-i))
 	}
 	// Bits 0-5, right of the top left finder pattern
 	for i := 0; i <= 5; i++ {
-		m.symbol.set(fpSize+1, i, f.At(l-i))
+		m.symbol.set(fpSize+1, i, at(l-i))
 	}
 	// Bits 6-8 on the corner of the top left finder pattern
-	m.symbol.set(fpSize+1, fpSize, f.At(l-6))
-	m.symbol.set(fpSize+1, fpSize+1, f.At(l-7))
-	m.symbol.set(fpSize, fpSize+1, f.At(l-8))
+	m.symbol.set(fpSize+1, fpSize, at(l-6))
+	m.symbol.set(fpSize+1, fpSize+1, at(l-7))
+	m.symbol.set(fpSize, fpSize+1, at(l-8))
 	// Bits 9-14 on the underside of the top left finder pattern
 	for i := 9; i <= 14; i++ {
-		m.symbol.set(14-i, fpSize+1, f.At(l-i))
+		m.symbol.set(14-i, fpSize+1, at(l-i))
 	}
 	// Bits 8-14 on the right side of the bottom left finder pattern
 	for i := 8; i <= 14; i++ {
-		m.symbol.set(fpSize+1, m.size-fpSize+i-8, f.At(l-i))
+		m.symbol.set(fpSize+1, m.size-fpSize+i-8, at(l-i))
 	}
 	// Always dark symbol
 	m.symbol.set(fpSize+1, m.size-fpSize-1, true)
@@ -243,27 +247,108 @@ func (m *regularSymbol) addData() (bool, error) {
 	return true, nil
 }
 
-func buildRegularSymbol(version qrCodeVersion, mask int,
-	data *bitset.Bitset, includeQuietZone bool) (*symbol, error) {
-	quietZoneSize := 0
-	if includeQuietZone {
-		quietZoneSize = version.quietZoneSize()
-	}
+// Builds the parts of a symbol which do not depend on the data mask: the finder, alignment and timing patterns and the version info
+// The patterns are built into dst, with a quiet zone quietZoneSize modules wide, which is returned
+// The result is shared by all mask candidates and must not be modified, see buildMaskedSymbol()
+func buildFunctionPatterns(version qrCodeVersion, quietZoneSize int, dst *symbol) *symbol {
+	dst.reset(version.symbolSize(), quietZoneSize)
 	m := &regularSymbol{
 		version: version,
-		mask:    mask,
-		data:    data,
-		symbol:  newSymbol(version.symbolSize(), quietZoneSize),
+		symbol:  dst,
 		size:    version.symbolSize(),
 	}
 	m.addFinderPatterns()
 	m.addAlignmentPatterns()
 	m.addTimingPatterns()
-	m.addFormatInfo()
 	m.addVersionInfo()
+	return m.symbol
+}
+
+// Returns the role of each module of a symbol of version, indexed by y*symbolSize()+x
+// The function patterns are added in the same order as by buildFunctionPatterns(), each claiming the modules it sets
+func buildModuleRoles(version qrCodeVersion) []ModuleRole {
+	size := version.symbolSize()
+	roles := make([]ModuleRole, size*size)
+	m := &regularSymbol{
+		version: version,
+		symbol:  newSymbol(size, 0),
+		size:    size,
+	}
+	claim := func(role ModuleRole) {
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if roles[y*size+x] == RoleData && !m.symbol.empty(x, y) {
+					roles[y*size+x] = role
+				}
+			}
+		}
+	}
+	m.addFinderPatterns()
+	claim(RoleFinder)
+	m.addAlignmentPatterns()
+	claim(RoleAlignment)
+	m.addTimingPatterns()
+	claim(RoleTiming)
+	m.addVersionInfo()
+	claim(RoleVersion)
+	m.addFormatInfo()
+	claim(RoleFormat)
+	return roles
+}
+
+// Returns the index in the final data sequence of the bit held by each module of a symbol of version, indexed by y*symbolSize()+x
+// Modules which are not data modules, as given by roles, have the index -1. The modules are visited in the order used by addData()
+func buildDataBitIndex(version qrCodeVersion, roles []ModuleRole) []int {
+	size := version.symbolSize()
+	index := make([]int, size*size)
+	for i := range index {
+		index[i] = -1
+	}
+	bit := 0
+	up := true
+	for right := size - 1; right >= 1; right -= 2 {
+		// Skip over the vertical timing pattern entirely
+		if right == 6 {
+			right = 5
+		}
+		for i := 0; i < size; i++ {
+			y := i
+			if up {
+				y = size - 1 - i
+			}
+			for x := right; x >= right-1; x-- {
+				if roles[y*size+x] == RoleData {
+					index[y*size+x] = bit
+					bit++
+				}
+			}
+		}
+		up = !up
+	}
+	return index
+}
+
+// Builds a symbol using mask into dst, on top of a copy of the function patterns returned by buildFunctionPatterns()
+func buildMaskedSymbol(version qrCodeVersion, mask int,
+	data *bitset.Bitset, functionPatterns *symbol, dst *symbol) (*symbol, error) {
+	dst.copyFrom(functionPatterns)
+	m := &regularSymbol{
+		version: version,
+		mask:    mask,
+		data:    data,
+		symbol:  dst,
+		size:    version.symbolSize(),
+	}
+	m.addFormatInfo()
 	ok, err := m.addData()
 	if !ok {
 		return nil, err
 	}
 	return m.symbol, nil
 }
+
+func buildRegularSymbol(version qrCodeVersion, mask int,
+	data *bitset.Bitset, quietZoneSize int) (*symbol, error) {
+	functionPatterns := buildFunctionPatterns(version, quietZoneSize, &symbol{})
+	return buildMaskedSymbol(version, mask, data, functionPatterns, &symbol{})
+}
@@ -0,0 +1,228 @@
+package getqr
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Media type of the text output formats
+const textContentType = "text/plain; charset=utf-8"
+
+// Matrix is an encoded QR Code, the modules a Renderer draws. See QRCode.Matrix()
+type Matrix struct {
+	Bitmap          [][]bool       // Modules indexed [y][x], including the quiet zone. True is dark
+	Size            int            // Width and height of Bitmap in modules
+	QuietZone       int            // Width of the quiet zone on each side of the symbol, in modules
+	Roles           [][]ModuleRole // Part of the symbol each module belongs to, indexed [y][x] excluding the quiet zone
+	ForegroundColor color.Color
+	BackgroundColor color.Color
+	qr              *QRCode // QR Code the matrix was encoded from, drawn by the built in renderers with its Style, Fill and logo
+}
+
+// Returns the encoded modules of the QR Code, with their roles and its colours, as given to a Renderer
+func (q *QRCode) Matrix() *Matrix {
+	bitmap := q.Bitmap()
+	size := q.version.symbolSize()
+	roles := buildModuleRoles(q.version)
+	m := &Matrix{
+		Bitmap:          bitmap,
+		Size:            len(bitmap),
+		QuietZone:       q.symbol.quietZoneSize,
+		Roles:           make([][]ModuleRole, size),
+		ForegroundColor: q.ForegroundColor,
+		BackgroundColor: q.BackgroundColor,
+		qr:              q,
+	}
+	for y := range m.Roles {
+		m.Roles[y] = roles[y*size : (y+1)*size]
+	}
+	return m
+}
+
+// Returns a copy of the QR Code m was encoded from, in the colours of m, for the built in renderers
+func (m *Matrix) qrCode() (*QRCode, error) {
+	if m.qr == nil {
+		return nil, errors.New("the built in renderers draw a Matrix returned by QRCode.Matrix()")
+	}
+	q := *m.qr
+	q.ForegroundColor, q.BackgroundColor = m.ForegroundColor, m.BackgroundColor
+	return &q, nil
+}
+
+// RenderOptions configures a Renderer. A nil *RenderOptions is the same as the zero value
+type RenderOptions struct {
+	Size         int         // Size of an image. See the documentation for Image(). Text formats ignore it
+	InverseColor bool        // Draw the dark modules instead of the light ones in text formats, see ToString()
+	Format       interface{} // Options of the format, used in place of Size: *SVGOptions for "svg", *PDFOptions for "pdf", *EPSOptions for "eps" and *jpeg.Options for "jpeg". Nil uses the defaults
+}
+
+// Renderer writes QR Codes in an output format. Renderers are registered by format name, see RegisterRenderer()
+type Renderer interface {
+	// Writes m to out. opts is never nil
+	Render(out io.Writer, m *Matrix, opts *RenderOptions) error
+	// Returns the media type of the output, e.g. "image/png", for a Content-Type header
+	ContentType() string
+}
+
+// rendererFunc is a built in Renderer, drawing the QR Code of the matrix by calling a function
+type rendererFunc struct {
+	contentType string
+	render      func(out io.Writer, q *QRCode, opts *RenderOptions) error
+}
+
+func (r rendererFunc) Render(out io.Writer, m *Matrix, opts *RenderOptions) error {
+	q, err := m.qrCode()
+	if err != nil {
+		return err
+	}
+	return r.render(out, q, opts)
+}
+
+func (r rendererFunc) ContentType() string {
+	return r.contentType
+}
+
+// textRenderer writes the modules as lines of text, drawn by text, see ToString()
+type textRenderer struct {
+	text func(bits [][]bool, inverseColor bool) string
+}
+
+func (r textRenderer) Render(out io.Writer, m *Matrix, opts *RenderOptions) error {
+	_, err := io.WriteString(out, r.text(m.Bitmap, opts.InverseColor))
+	return err
+}
+
+func (textRenderer) ContentType() string {
+	return textContentType
+}
+
+// Returns an error for format options of the wrong type, want being a value of the right one
+func formatOptionsError(format string, got, want interface{}) error {
+	return fmt.Errorf("%s output takes %T options, not %T", format, want, got)
+}
+
+var (
+	renderersMu sync.RWMutex
+	// Renderers by format name
+	renderers = map[string]Renderer{
+		"png": rendererFunc{"image/png", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.Write(opts.Size, out)
+		}},
+		"txt":         textRenderer{blockText},
+		"txt-small":   textRenderer{smallBlockText},
+		"txt-quarter": textRenderer{quarterText},
+		"txt-braille": textRenderer{brailleText},
+		"gif": rendererFunc{"image/gif", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.WriteGIF(out, opts.Size)
+		}},
+		"jpeg": rendererFunc{"image/jpeg", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			jpegOpts, ok := opts.Format.(*jpeg.Options)
+			if !ok && opts.Format != nil {
+				return formatOptionsError("jpeg", opts.Format, jpegOpts)
+			}
+			return q.WriteJPEG(out, opts.Size, jpegOpts)
+		}},
+		"bmp": rendererFunc{"image/bmp", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.WriteBMP(out, opts.Size)
+		}},
+		"pbm": rendererFunc{"image/x-portable-bitmap", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.WritePBM(out, opts.Size, false)
+		}},
+		"svg": rendererFunc{"image/svg+xml", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			svg, ok := opts.Format.(*SVGOptions)
+			if !ok {
+				if opts.Format != nil {
+					return formatOptionsError("svg", opts.Format, svg)
+				}
+				// As for images, a positive size is the width and a negative one the module size
+				svg = &SVGOptions{ModuleSize: float64(-opts.Size)}
+				if opts.Size > 0 {
+					svg.ModuleSize = float64(opts.Size) / float64(q.bitmapSize())
+				}
+			}
+			return q.WriteSVG(out, svg)
+		}},
+		"pdf": rendererFunc{"application/pdf", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			pdf, ok := opts.Format.(*PDFOptions)
+			if !ok {
+				if opts.Format != nil {
+					return formatOptionsError("pdf", opts.Format, pdf)
+				}
+				// Modules of 1mm, as for EPS
+				pdf = &PDFOptions{ModuleSize: 1}
+			}
+			return q.WritePDF(out, pdf)
+		}},
+		"eps": rendererFunc{"application/postscript", func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			eps, ok := opts.Format.(*EPSOptions)
+			if !ok && opts.Format != nil {
+				return formatOptionsError("eps", opts.Format, eps)
+			}
+			return q.WriteEPS(out, eps)
+		}},
+		"ansi": rendererFunc{textContentType, func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.WriteANSI(out)
+		}},
+		"sixel": rendererFunc{textContentType, func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.WriteSixel(out, opts.Size)
+		}},
+		"kitty": rendererFunc{textContentType, func(out io.Writer, q *QRCode, opts *RenderOptions) error {
+			return q.WriteKitty(out, opts.Size)
+		}},
+	}
+)
+
+// Registers r as the renderer of the output format named format, for Render() and LookupRenderer()
+// Built in formats are "png", "gif", "jpeg", "bmp", "pbm", "svg", "pdf", "eps", the text formats "txt", "txt-small",
+// "txt-quarter" and "txt-braille", and the terminal formats "ansi", "sixel" and "kitty"
+// RegisterRenderer panics if format is empty or already registered, or r is nil
+func RegisterRenderer(format string, r Renderer) {
+	if format == "" || r == nil {
+		panic("getqr: RegisterRenderer needs a format name and a renderer")
+	}
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if _, ok := renderers[format]; ok {
+		panic(fmt.Sprintf("getqr: RegisterRenderer called twice for format %q", format))
+	}
+	renderers[format] = r
+}
+
+// Returns the renderer registered for format, and whether there is one
+func LookupRenderer(format string) (Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[format]
+	return r, ok
+}
+
+// Returns the names of the registered output formats, sorted
+func RendererFormats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Writes the Matrix of the QR Code to out in the output format named format, see RegisterRenderer()
+// A nil opts is the same as the zero value
+func (q *QRCode) Render(out io.Writer, format string, opts *RenderOptions) error {
+	r, ok := LookupRenderer(format)
+	if !ok {
+		return fmt.Errorf("unknown output format %q (expected one of %s)", format, strings.Join(RendererFormats(), ", "))
+	}
+	if opts == nil {
+		opts = &RenderOptions{}
+	}
+	return r.Render(out, q.Matrix(), opts)
+}
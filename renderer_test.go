@@ -0,0 +1,178 @@
+package getqr
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// countRenderer writes the number of dark modules of a QR Code
+type countRenderer struct{}
+
+func (countRenderer) Render(out io.Writer, m *Matrix, opts *RenderOptions) error {
+	n := 0
+	for _, row := range m.Bitmap {
+		for _, v := range row {
+			if v != opts.InverseColor {
+				n++
+			}
+		}
+	}
+	_, err := fmt.Fprintln(out, n)
+	return err
+}
+
+func (countRenderer) ContentType() string {
+	return "text/plain"
+}
+
+func TestRender(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png, err := q.PNG(-3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Format options are used as by the QRCode methods
+	svgOpts := &SVGOptions{ModuleSize: 0.5, Unit: "mm", Title: "getqr"}
+	svg, err := q.SVG(svgOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdfOpts := &PDFOptions{Size: 30, Bleed: 2, CropMarks: true}
+	pdf, err := q.PDF(pdfOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	epsOpts := &EPSOptions{ModuleSize: 0.5, CMYK: true}
+	eps, err := q.EPS(epsOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		format      string
+		opts        *RenderOptions
+		want        string
+		contentType string
+	}{
+		{"png", &RenderOptions{Size: -3}, string(png), "image/png"},
+		{"txt", nil, q.ToString(false), textContentType},
+		{"txt-small", &RenderOptions{InverseColor: true}, q.ToSmallString(true), textContentType},
+		{"txt-quarter", nil, q.ToQuarterString(false), textContentType},
+		{"txt-braille", nil, q.ToBrailleString(false), textContentType},
+		{"ansi", nil, q.ANSIString(), textContentType},
+		{"svg", &RenderOptions{Size: 100, Format: svgOpts}, string(svg), "image/svg+xml"},
+		{"pdf", &RenderOptions{Format: pdfOpts}, string(pdf), "application/pdf"},
+		{"eps", &RenderOptions{Format: epsOpts}, string(eps), "application/postscript"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		if err := q.Render(&b, test.format, test.opts); err != nil {
+			t.Fatalf("%s: %v", test.format, err)
+		}
+		if b.String() != test.want {
+			t.Errorf("%s: output differs from the QRCode method", test.format)
+		}
+		r, ok := LookupRenderer(test.format)
+		if !ok || r.ContentType() != test.contentType {
+			t.Errorf("%s: got content type %v, want %q", test.format, r, test.contentType)
+		}
+	}
+	// Image formats sized by opts.Size
+	for _, format := range []string{"gif", "jpeg", "bmp", "pbm", "svg", "pdf", "eps", "sixel", "kitty"} {
+		var b bytes.Buffer
+		if err := q.Render(&b, format, &RenderOptions{Size: 100}); err != nil || b.Len() == 0 {
+			t.Errorf("%s: got %d bytes, error %v", format, b.Len(), err)
+		}
+	}
+	if err := q.Render(io.Discard, "webp", nil); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if err := q.Render(io.Discard, "svg", &RenderOptions{Format: pdfOpts}); err == nil {
+		t.Error("expected an error for PDF options in SVG output")
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.QuietZone = 2
+	m := q.Matrix()
+	symbolSize := q.version.symbolSize()
+	if m.Size != symbolSize+4 || m.QuietZone != 2 || !reflect.DeepEqual(m.Bitmap, q.Bitmap()) {
+		t.Fatalf("got a matrix of %d modules with a quiet zone of %d, want the bitmap of %d modules with 2",
+			m.Size, m.QuietZone, symbolSize+4)
+	}
+	if len(m.Roles) != symbolSize || m.Roles[0][0] != RoleFinder || m.Roles[6][symbolSize/2] != RoleTiming ||
+		m.Roles[symbolSize/2][symbolSize/2] != RoleData {
+		t.Errorf("unexpected module roles %v", m.Roles)
+	}
+	// Built in renderers draw in the colours of the matrix
+	q.ForegroundColor = color.NRGBA{0x20, 0x40, 0x80, 0xff}
+	want := q.ANSIString()
+	q.ForegroundColor = color.Black
+	m = q.Matrix()
+	m.ForegroundColor = color.NRGBA{0x20, 0x40, 0x80, 0xff}
+	r, _ := LookupRenderer("ansi")
+	var b bytes.Buffer
+	if err := r.Render(&b, m, &RenderOptions{}); err != nil || b.String() != want {
+		t.Errorf("ansi renderer ignored the matrix colours: %v", err)
+	}
+	// A matrix made by the caller can be drawn by the text formats, but not the styled built in ones
+	own := &Matrix{Bitmap: m.Bitmap, Size: m.Size, QuietZone: m.QuietZone}
+	r, _ = LookupRenderer("txt")
+	b.Reset()
+	if err := r.Render(&b, own, &RenderOptions{}); err != nil || b.String() != q.ToString(false) {
+		t.Errorf("txt renderer failed on a caller's matrix: %v", err)
+	}
+	r, _ = LookupRenderer("png")
+	if err := r.Render(io.Discard, own, &RenderOptions{}); err == nil {
+		t.Error("expected an error drawing a caller's matrix as PNG")
+	}
+}
+
+func TestRegisterRenderer(t *testing.T) {
+	// Registered once, as the registry outlives repeated test runs
+	if _, ok := LookupRenderer("test-count"); !ok {
+		RegisterRenderer("test-count", countRenderer{})
+	}
+	formats := RendererFormats()
+	if !sort.StringsAreSorted(formats) {
+		t.Errorf("formats %v are not sorted", formats)
+	}
+	i := sort.SearchStrings(formats, "test-count")
+	if i == len(formats) || formats[i] != "test-count" {
+		t.Errorf("formats %v do not include test-count", formats)
+	}
+	q, err := New("hello", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := q.Render(&b, "test-count", nil); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	countRenderer{}.Render(&buf, q.Matrix(), &RenderOptions{})
+	if b.String() != buf.String() || b.Len() < 2 {
+		t.Errorf("got %q, want %q", b.String(), buf.String())
+	}
+	for _, format := range []string{"png", "test-count", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: expected a panic", format)
+				}
+			}()
+			RegisterRenderer(format, countRenderer{})
+		}()
+	}
+}
@@ -0,0 +1,111 @@
+package getqr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+
+	bitset "github.com/pchchv/getqr/bitset"
+)
+
+// Encoder encodes and renders QR Codes repeatedly, reusing its buffers between calls
+// Once warmed up, an Encoder allocates next to nothing per QR Code, which suits bulk generation
+// Images and byte slices returned by an Encoder are only valid until its next call
+// An Encoder must not be used concurrently: use one Encoder per goroutine instead
+type Encoder struct {
+	Level           RecoveryLevel // QR Code type
+	BackgroundColor color.Color   // User settable drawing options
+	ForegroundColor color.Color
+	QuietZone       int     // Width of the quiet zone in modules. NewEncoder() sets the standard width of 4, see QRCode.QuietZone
+	DisableBorder   bool    // Deprecated: set QuietZone to 0 instead
+	IntegerScaling  bool    // Draw images with a whole number of pixels per module. See QRCode.IntegerScaling
+	MinContrast     float64 // Minimum contrast ratio of the colours. See QRCode.MinContrast
+	q               QRCode
+	encoders        [3]*dataEncoder
+	content         []byte
+	data            *bitset.Bitset
+	buffers         encodeBuffers
+	img             *image.Paletted
+	png             bytes.Buffer
+}
+
+// Constructs an Encoder for QR Codes with the recovery level level
+func NewEncoder(level RecoveryLevel) *Encoder {
+	return &Encoder{
+		Level:           level,
+		ForegroundColor: color.Black,
+		BackgroundColor: color.White,
+		QuietZone:       qrCodeVersion{}.quietZoneSize(),
+		encoders: [...]*dataEncoder{
+			newDataEncoder(dataEncoderType1To9),
+			newDataEncoder(dataEncoderType10To26),
+			newDataEncoder(dataEncoderType27To40),
+		},
+		data: bitset.New(),
+		// Callers wanting throughput run one Encoder per goroutine, so there is nothing to gain from concurrent mask evaluation
+		buffers: encodeBuffers{sequential: true},
+	}
+}
+
+// Encodes content, leaving the result in e.q
+func (e *Encoder) encode(content string) error {
+	e.content = append(e.content[:0], content...)
+	encoder, chosenVersion, err := chooseEncoding(e.content, e.Level, e.encoders[:], e.data)
+	if err != nil {
+		return err
+	}
+	e.q = QRCode{
+		Content:         content,
+		Level:           e.Level,
+		VersionNumber:   chosenVersion.version,
+		ForegroundColor: e.ForegroundColor,
+		BackgroundColor: e.BackgroundColor,
+		QuietZone:       e.QuietZone,
+		DisableBorder:   e.DisableBorder,
+		IntegerScaling:  e.IntegerScaling,
+		MinContrast:     e.MinContrast,
+		encoder:         encoder,
+		data:            e.data,
+		version:         *chosenVersion,
+	}
+	e.q.encodeWith(&e.buffers)
+	return nil
+}
+
+// Encodes content and returns the QR Code as an image.Image
+// See the documentation of QRCode.Image() for the meaning of size
+// The image is only valid until the next call to e
+func (e *Encoder) Image(content string, size int) (image.Image, error) {
+	if err := e.encode(content); err != nil {
+		return nil, err
+	}
+	if err := e.q.validateContrast(); err != nil {
+		return nil, err
+	}
+	e.img = drawPaletted(e.q.symbol, e.q.ImageLayout(size), e.BackgroundColor, e.ForegroundColor, e.img)
+	return e.img, nil
+}
+
+// Encodes content and returns the QR Code as a PNG image
+// See the documentation of QRCode.PNG() for the meaning of size
+// The returned slice is only valid until the next call to e
+func (e *Encoder) PNG(content string, size int) ([]byte, error) {
+	e.png.Reset()
+	if err := e.Write(content, size, &e.png); err != nil {
+		return nil, err
+	}
+	return e.png.Bytes(), nil
+}
+
+// Encodes content and writes the QR Code as a PNG image to out
+// See the documentation of QRCode.PNG() for the meaning of size
+func (e *Encoder) Write(content string, size int, out io.Writer) error {
+	if err := e.encode(content); err != nil {
+		return err
+	}
+	if err := e.q.validateContrast(); err != nil {
+		return err
+	}
+	return writePNG(out, e.q.symbol, e.q.ImageLayout(size), e.BackgroundColor, e.ForegroundColor)
+}
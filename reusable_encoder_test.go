@@ -0,0 +1,87 @@
+package getqr
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestEncoderMatchesQRCode(t *testing.T) {
+	e := NewEncoder(Medium)
+	// Alternate between sizes and settings, so reused buffers must be resized and cleared
+	contents := []string{"a", strings.Repeat("0123456789", 60), "HELLO WORLD", strings.Repeat("getqr ", 300), "b"}
+	for i, content := range contents {
+		e.DisableBorder = i%2 == 1
+		if i == 3 {
+			e.ForegroundColor = color.RGBA{0x20, 0x40, 0x80, 0xff}
+		}
+		q, err := New(content, Medium)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q.DisableBorder = e.DisableBorder
+		q.ForegroundColor = e.ForegroundColor
+		for _, size := range []int{-2, 256} {
+			want, err := q.PNG(size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := e.PNG(content, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("content %d size %d: Encoder.PNG differs from QRCode.PNG", i, size)
+			}
+		}
+	}
+	if _, err := e.PNG(strings.Repeat("x", 5000), 100); err == nil {
+		t.Error("Encoder.PNG of content too long to encode succeeded, want error")
+	}
+}
+
+func BenchmarkEncoderImage(b *testing.B) {
+	for _, version := range []int{1, 10, 25, 40} {
+		content := strings.Repeat("getqr", 2*version)
+		b.Run(fmt.Sprintf("version%d", version), func(b *testing.B) {
+			e := NewEncoder(Low)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.Image(content, 512); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEncoderPNG(b *testing.B) {
+	for _, version := range []int{1, 10, 25, 40} {
+		content := strings.Repeat("getqr", 2*version)
+		b.Run(fmt.Sprintf("version%d", version), func(b *testing.B) {
+			e := NewEncoder(Low)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.PNG(content, 512); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkQRCodePNG(b *testing.B) {
+	for _, version := range []int{1, 10, 25, 40} {
+		content := strings.Repeat("getqr", 2*version)
+		b.Run(fmt.Sprintf("version%d", version), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Encode(content, Low, 512); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
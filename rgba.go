@@ -0,0 +1,140 @@
+package getqr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Smallest difference, in any channel of the alpha-premultiplied 8-bit colours,
+// for the background and foreground colours to be told apart
+const minColorDifference = 0x10
+
+// Returns the QR Code as a non-premultiplied truecolour image
+// Alpha is kept as given, so a transparent BackgroundColor and a semi-transparent ForegroundColor are drawn exactly
+// See the documentation for Image() for the meaning of size
+// An error is returned if BackgroundColor and ForegroundColor are too similar to tell apart
+func (q *QRCode) NRGBAImage(size int) (*image.NRGBA, error) {
+	if err := q.validateContrast(); err != nil {
+		return nil, err
+	}
+	// Build QR code
+	q.encode()
+	return q.drawNRGBA(q.ImageLayout(size)), nil
+}
+
+// Returns the QR Code as an alpha-premultiplied truecolour image. See the documentation for NRGBAImage()
+func (q *QRCode) RGBAImage(size int) (*image.RGBA, error) {
+	if err := q.validateContrast(); err != nil {
+		return nil, err
+	}
+	// Build QR code
+	q.encode()
+	l := q.ImageLayout(size)
+	img := image.NewRGBA(image.Rect(0, 0, l.Size, l.Size))
+	q.drawTruecolor(l, img.Pix, img.Stride, rgbaPixel)
+	if q.logo != nil {
+		q.logo.draw(img, l, q.quietZoneSize())
+	}
+	return img, nil
+}
+
+// Returns the encoded QR Code as a non-premultiplied image with layout l, drawn in its Style and with its logo
+func (q *QRCode) drawNRGBA(l ImageLayout) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, l.Size, l.Size))
+	q.drawTruecolor(l, img.Pix, img.Stride, nrgbaPixel)
+	if q.logo != nil {
+		q.logo.draw(img, l, q.quietZoneSize())
+	}
+	return img
+}
+
+// Draws the modules of the encoded QR Code with layout l into the 4 byte per pixel image pix with row stride stride
+// pixel returns the bytes of a colour in the format of the image
+func (q *QRCode) drawTruecolor(l ImageLayout, pix []byte, stride int, pixel func(color.Color) [4]byte) {
+	q.truecolorRows(l, pixel)(pix, stride, 0, l.Size)
+}
+
+// Returns a function drawing the pixel rows y0 to y1-1 of the encoded QR Code with layout l into the 4 byte per pixel
+// image pix with row stride stride, which holds only those rows. pixel returns the bytes of a colour in the format of the image
+// The shapes and colours of the modules are worked out once, so an image can be drawn a few rows at a time
+func (q *QRCode) truecolorRows(l ImageLayout, pixel func(color.Color) [4]byte) func(pix []byte, stride, y0, y1 int) {
+	if q.ModuleColor != nil {
+		values := q.moduleColorValues(pixel)
+		bg := pixel(q.BackgroundColor)
+		return func(pix []byte, stride, y0, y1 int) {
+			q.drawModuleColors(l, values, bg, pix, stride, y0, y1)
+		}
+	}
+	if q.Style != nil || q.Fill != nil {
+		groups := q.styledGroups()
+		quietZoneSize := q.quietZoneSize()
+		fill := q.drawableFill()
+		return func(pix []byte, stride, y0, y1 int) {
+			drawStyled(groups, l, quietZoneSize, fill, pix, stride, y0, y1, q.BackgroundColor, pixel)
+		}
+	}
+	bg, fg := pixel(q.BackgroundColor), pixel(q.ForegroundColor)
+	return func(pix []byte, stride, y0, y1 int) {
+		drawPixels(q.symbol, l, pix, stride, y0, y1, bg, fg)
+	}
+}
+
+// Draws the pixel rows y0 to y1-1 of the symbol with layout l into the 4 byte per pixel image pix
+// with row stride stride, which holds only those rows
+func drawPixels(s *symbol, l ImageLayout, pix []byte, stride, y0, y1 int, background, foreground [4]byte) {
+	size := l.Size
+	for y := y0; y < y1; y++ {
+		y2 := l.module(y)
+		row := pix[(y-y0)*stride : (y-y0)*stride+4*size]
+		// Consecutive pixel rows usually map to the same module row
+		if y > y0 && l.module(y-1) == y2 {
+			copy(row, pix[(y-1-y0)*stride:])
+			continue
+		}
+		for x := 0; x < size; x++ {
+			if s.bitmapAt(l.module(x), y2) {
+				copy(row[4*x:], foreground[:])
+			} else {
+				copy(row[4*x:], background[:])
+			}
+		}
+	}
+}
+
+// Returns the bytes of c in an image.NRGBA
+func nrgbaPixel(c color.Color) [4]byte {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return [4]byte{n.R, n.G, n.B, n.A}
+}
+
+// Returns the bytes of c in an image.RGBA
+func rgbaPixel(c color.Color) [4]byte {
+	n := color.RGBAModel.Convert(c).(color.RGBA)
+	return [4]byte{n.R, n.G, n.B, n.A}
+}
+
+// Returns an error if background and foreground are missing, or too similar to tell apart
+// The colours are compared alpha-premultiplied, so all fully transparent colours are the same
+func validateColorPair(background, foreground color.Color) error {
+	if background == nil || foreground == nil {
+		return errors.New("background and foreground colours must be set")
+	}
+	bg := color.RGBAModel.Convert(background).(color.RGBA)
+	fg := color.RGBAModel.Convert(foreground).(color.RGBA)
+	diff := max(absDiff(bg.R, fg.R), absDiff(bg.G, fg.G))
+	diff = max(diff, max(absDiff(bg.B, fg.B), absDiff(bg.A, fg.A)))
+	if diff < minColorDifference {
+		return fmt.Errorf("background %v and foreground %v colours are too similar", background, foreground)
+	}
+	return nil
+}
+
+// Returns the absolute difference of a and b
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
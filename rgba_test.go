@@ -0,0 +1,76 @@
+package getqr
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestNRGBAImage(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.BackgroundColor = color.Transparent
+	q.ForegroundColor = color.NRGBA{0x20, 0x40, 0x80, 0x80}
+	for _, size := range []int{-3, 100} {
+		img, err := q.NRGBAImage(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paletted := q.Image(size)
+		if img.Bounds() != paletted.Bounds() {
+			t.Fatalf("size %d: got bounds %v, want %v", size, img.Bounds(), paletted.Bounds())
+		}
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				want := color.NRGBA{}
+				if sameColor(paletted.At(x, y), q.ForegroundColor) {
+					want = q.ForegroundColor.(color.NRGBA)
+				}
+				if got := img.NRGBAAt(x, y); got != want {
+					t.Fatalf("size %d: pixel (%d, %d) is %v, want %v", size, x, y, got, want)
+				}
+			}
+		}
+	}
+	rgba, err := q.RGBAImage(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The top left finder pattern is dark
+	qz := q.QuietZone
+	if got, want := rgba.RGBAAt(qz, qz), (color.RGBA{0x10, 0x20, 0x40, 0x80}); got != want {
+		t.Errorf("got premultiplied %v, want %v", got, want)
+	}
+	if got := rgba.RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Errorf("got background %v, want transparent", got)
+	}
+}
+
+func TestValidateColorPair(t *testing.T) {
+	tests := []struct {
+		background, foreground color.Color
+		ok                     bool
+	}{
+		{color.White, color.Black, true},
+		{color.Transparent, color.Black, true},
+		{color.White, color.White, false},
+		{color.White, color.RGBA{0xf8, 0xf8, 0xf8, 0xff}, false},
+		{color.Transparent, color.NRGBA{0xff, 0xff, 0xff, 0x00}, false},
+		{nil, color.Black, false},
+	}
+	for _, test := range tests {
+		err := validateColorPair(test.background, test.foreground)
+		if (err == nil) != test.ok {
+			t.Errorf("%v on %v: got error %v", test.foreground, test.background, err)
+		}
+	}
+	q, err := New("getqr", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ForegroundColor = q.BackgroundColor
+	if _, err := q.NRGBAImage(-1); err == nil {
+		t.Error("NRGBAImage() accepted identical colours")
+	}
+}
@@ -0,0 +1,335 @@
+package getqr
+
+import (
+	"image/color"
+	"io"
+	"math"
+)
+
+// Distance of the Bézier control points from the ends of a quarter circle arc of radius 1
+const bezierCircle = 0.5523
+
+// Corner radius of ModuleRounded modules, in modules
+const roundedModuleRadius = 0.3
+
+// ModuleShape is the shape in which dark data modules are drawn
+type ModuleShape int
+
+const (
+	// Squares, which join up into solid areas
+	ModuleSquare ModuleShape = iota
+	// Circles touching their neighbours, giving a dotted look
+	ModuleCircle
+	// Squares with rounded corners
+	ModuleRounded
+	// Each horizontal run of dark modules as a single bar with round ends
+	ModuleConnected
+)
+
+// PatternShape is the shape of a part of a finder or alignment pattern
+type PatternShape int
+
+const (
+	PatternSquare PatternShape = iota
+	PatternRounded
+	PatternCircle
+)
+
+// PatternStyle styles the finder or alignment patterns
+// The outer part is the dark ring, and the inner part the dark centre, or eye
+type PatternStyle struct {
+	Outer      PatternShape
+	Inner      PatternShape
+	OuterColor color.Color // Nil uses ForegroundColor
+	InnerColor color.Color // Nil uses ForegroundColor
+}
+
+// Style sets the shapes and colours of a styled QR Code, see QRCode.Style
+// The timing patterns and the format and version information are always drawn as squares, to keep them legible
+type Style struct {
+	Modules   ModuleShape // Shape of the data modules
+	Finder    PatternStyle
+	Alignment PatternStyle
+}
+
+// roundedRect is a rectangle with corners rounded to radius r. Positions and sizes are in modules
+type roundedRect struct {
+	x, y, w, h, r float64
+}
+
+// Reports whether the point (x, y) is inside the rectangle
+func (rr roundedRect) contains(x, y float64) bool {
+	if x < rr.x || y < rr.y || x >= rr.x+rr.w || y >= rr.y+rr.h {
+		return false
+	}
+	// Distance from the rectangle inset by r
+	dx := math.Max(math.Max(rr.x+rr.r-x, x-(rr.x+rr.w-rr.r)), 0)
+	dy := math.Max(math.Max(rr.y+rr.r-y, y-(rr.y+rr.h-rr.r)), 0)
+	return dx*dx+dy*dy <= rr.r*rr.r
+}
+
+// Writes the outline of the rectangle to f as a closed subpath, clockwise with the y axis pointing down
+func (rr roundedRect) path(f *pathFormatter) {
+	x0, y0, x1, y1, r := rr.x, rr.y, rr.x+rr.w, rr.y+rr.h, rr.r
+	k := bezierCircle * r
+	f.moveTo(x0+r, y0)
+	if rr.w > 2*r {
+		f.lineTo(x1-r, y0)
+	}
+	if r > 0 {
+		f.curveTo(x1-r+k, y0, x1, y0+r-k, x1, y0+r)
+	}
+	if rr.h > 2*r {
+		f.lineTo(x1, y1-r)
+	}
+	if r > 0 {
+		f.curveTo(x1, y1-r+k, x1-r+k, y1, x1-r, y1)
+	}
+	if rr.w > 2*r {
+		f.lineTo(x0+r, y1)
+	}
+	if r > 0 {
+		f.curveTo(x0+r-k, y1, x0, y1-r+k, x0, y1-r)
+	}
+	if rr.h > 2*r {
+		f.lineTo(x0, y0+r)
+	}
+	if r > 0 {
+		f.curveTo(x0, y0+r-k, x0+r-k, y0, x0+r, y0)
+	}
+	f.closePath()
+}
+
+// styledShape is a rounded rectangle, optionally with a rounded rectangular hole
+type styledShape struct {
+	outer   roundedRect
+	hole    roundedRect
+	hasHole bool
+}
+
+func (s styledShape) contains(x, y float64) bool {
+	return s.outer.contains(x, y) && !(s.hasHole && s.hole.contains(x, y))
+}
+
+// styledGroup is the shapes drawn in one colour
+type styledGroup struct {
+	color      color.Color
+	foreground bool // Drawn in ForegroundColor, which the QR Code's Fill replaces
+	shapes     []styledShape
+}
+
+// Writes the shapes to f. The holes are cut out using the even-odd fill rule
+func (g *styledGroup) path(f *pathFormatter) {
+	for _, s := range g.shapes {
+		s.outer.path(f)
+		if s.hasHole {
+			s.hole.path(f)
+		}
+	}
+}
+
+// Returns the shapes of the dark modules of the styled QR Code, grouped by colour
+// Positions are in modules from the top left corner of the symbol, excluding the quiet zone. The QR Code must be encoded
+func (q *QRCode) styledGroups() []styledGroup {
+	style := q.Style
+	if style == nil {
+		style = &Style{}
+	}
+	s := q.symbol
+	size := s.symbolSize
+	roles := buildModuleRoles(q.version)
+	var groups []styledGroup
+	add := func(c color.Color, shape styledShape) {
+		foreground := c == nil
+		if foreground {
+			c = q.ForegroundColor
+		}
+		// Colours are compared as values, as a color.Color may not be comparable
+		key := color.NRGBAModel.Convert(c)
+		for i := range groups {
+			if color.NRGBAModel.Convert(groups[i].color) == key && groups[i].foreground == foreground {
+				groups[i].shapes = append(groups[i].shapes, shape)
+				return
+			}
+		}
+		groups = append(groups, styledGroup{color: c, foreground: foreground, shapes: []styledShape{shape}})
+	}
+	// Adds a finder or alignment pattern of width w with its top left corner at (x, y)
+	addPattern := func(p PatternStyle, x, y, w int) {
+		fx, fy, fw := float64(x), float64(y), float64(w)
+		add(p.OuterColor, styledShape{
+			outer:   roundedRect{fx, fy, fw, fw, patternRadius(p.Outer, fw)},
+			hole:    roundedRect{fx + 1, fy + 1, fw - 2, fw - 2, patternRadius(p.Outer, fw-2)},
+			hasHole: true,
+		})
+		add(p.InnerColor, styledShape{
+			outer: roundedRect{fx + 2, fy + 2, fw - 4, fw - 4, patternRadius(p.Inner, fw-4)},
+		})
+	}
+	addPattern(style.Finder, 0, 0, finderPatternSize)
+	addPattern(style.Finder, size-finderPatternSize, 0, finderPatternSize)
+	addPattern(style.Finder, 0, size-finderPatternSize, finderPatternSize)
+	centres := alignmentPatternCenter[q.version.version]
+	for _, x := range centres {
+		for _, y := range centres {
+			if roles[y*size+x] == RoleAlignment {
+				addPattern(style.Alignment, x-2, y-2, 5)
+			}
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if !s.get(x, y) {
+				continue
+			}
+			fx, fy := float64(x), float64(y)
+			switch roles[y*size+x] {
+			case RoleFinder, RoleAlignment:
+				// Drawn as patterns
+			case RoleData:
+				switch style.Modules {
+				case ModuleCircle:
+					add(nil, styledShape{outer: roundedRect{fx, fy, 1, 1, 0.5}})
+				case ModuleRounded:
+					add(nil, styledShape{outer: roundedRect{fx, fy, 1, 1, roundedModuleRadius}})
+				case ModuleConnected:
+					// Extend the run over the following dark data modules
+					n := 1
+					for x+n < size && s.get(x+n, y) && roles[y*size+x+n] == RoleData {
+						n++
+					}
+					add(nil, styledShape{outer: roundedRect{fx, fy, float64(n), 1, 0.5}})
+					x += n - 1
+				default:
+					add(nil, styledShape{outer: roundedRect{fx, fy, 1, 1, 0}})
+				}
+			default:
+				add(nil, styledShape{outer: roundedRect{fx, fy, 1, 1, 0}})
+			}
+		}
+	}
+	return groups
+}
+
+// Returns the corner radius of a part of a pattern of width w drawn as shape
+func patternRadius(shape PatternShape, w float64) float64 {
+	switch shape {
+	case PatternRounded:
+		return w * 2 / 7
+	case PatternCircle:
+		return w / 2
+	}
+	return 0
+}
+
+// Draws the pixel rows y0 to y1-1 of groups with layout l into the 4 byte per pixel image pix with row stride stride,
+// which holds only those rows. Each pixel is given the colour of the shape containing its centre, or else the background colour
+// If fill is not nil, it colours the shapes drawn in the foreground colour instead
+// pixel returns the bytes of a colour in the format of the image
+func drawStyled(groups []styledGroup, l ImageLayout, quietZoneSize int, fill Fill, pix []byte, stride, y0, y1 int,
+	background color.Color, pixel func(color.Color) [4]byte) {
+	size := l.Size
+	bg := pixel(background)
+	for y := y0; y < y1; y++ {
+		row := pix[(y-y0)*stride : (y-y0)*stride+4*size]
+		for x := 0; x < size; x++ {
+			copy(row[4*x:], bg[:])
+		}
+	}
+	qz := float64(quietZoneSize)
+	// Returns the range of pixels from lo to hi-1 covering the modules from m to m+n
+	pixels := func(m, n float64, lo, hi int) (int, int) {
+		p0 := int(math.Floor(l.pixelCoord(m + qz)))
+		p1 := int(math.Ceil(l.pixelCoord(m + n + qz)))
+		if p1 > hi {
+			p1 = hi
+		}
+		return max(p0, lo), p1
+	}
+	// Width of the symbol, to which the fill positions are relative
+	symbolSize := float64(l.modules - 2*quietZoneSize)
+	for _, g := range groups {
+		c := pixel(g.color)
+		filled := fill != nil && g.foreground
+		for _, s := range g.shapes {
+			sx0, sx1 := pixels(s.outer.x, s.outer.w, 0, size)
+			sy0, sy1 := pixels(s.outer.y, s.outer.h, y0, y1)
+			for y := sy0; y < sy1; y++ {
+				my := l.moduleCoord(y) - qz
+				row := pix[(y-y0)*stride:]
+				for x := sx0; x < sx1; x++ {
+					mx := l.moduleCoord(x) - qz
+					if !s.contains(mx, my) {
+						continue
+					}
+					if filled {
+						c = pixel(fill.At(mx/symbolSize, my/symbolSize))
+					}
+					copy(row[4*x:], c[:])
+				}
+			}
+		}
+	}
+}
+
+// pathFormatter writes path segments in the syntax of a vector format, translated by (dx, dy)
+type pathFormatter struct {
+	w      io.Writer
+	dx, dy float64
+	ops    *pathOperators
+}
+
+// pathOperators are the operators of a vector format's path syntax
+type pathOperators struct {
+	prefix                   bool // The operator comes before its operands, as in SVG
+	move, line, curve, close string
+}
+
+var (
+	svgPathOperators = &pathOperators{prefix: true, move: "M", line: "L", curve: "C", close: "Z"}
+	pdfPathOperators = &pathOperators{move: "m", line: "l", curve: "c", close: "h"}
+	epsPathOperators = &pathOperators{move: "moveto", line: "lineto", curve: "curveto", close: "closepath"}
+)
+
+func (f *pathFormatter) moveTo(x, y float64) {
+	f.segment(f.ops.move, x, y)
+}
+
+func (f *pathFormatter) lineTo(x, y float64) {
+	f.segment(f.ops.line, x, y)
+}
+
+func (f *pathFormatter) curveTo(x1, y1, x2, y2, x, y float64) {
+	f.segment(f.ops.curve, x1, y1, x2, y2, x, y)
+}
+
+func (f *pathFormatter) closePath() {
+	f.segment(f.ops.close)
+}
+
+// Writes the operator op with the points coords, given as x and y pairs
+func (f *pathFormatter) segment(op string, coords ...float64) {
+	var b []byte
+	if f.ops.prefix {
+		b = append(b, op...)
+	}
+	for i, v := range coords {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		if i%2 == 0 {
+			v += f.dx
+		} else {
+			v += f.dy
+		}
+		b = append(b, formatNumber(v)...)
+	}
+	if !f.ops.prefix {
+		if len(coords) > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, op...)
+		b = append(b, '\n')
+	}
+	f.w.Write(b)
+}
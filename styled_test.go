@@ -0,0 +1,155 @@
+package getqr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestBuildModuleRoles(t *testing.T) {
+	v := getQRCodeVersion(Medium, 7)
+	roles := buildModuleRoles(*v)
+	var counts [RoleFormat + 1]int
+	for _, role := range roles {
+		counts[role]++
+	}
+	// 8x8 finder patterns with separators, 6 alignment patterns, two timing lines less the alignment patterns crossing them,
+	// 2x18 bits of version info and 2x15 bits of format info plus the dark module
+	want := [...]int{
+		RoleFinder:    3 * 64,
+		RoleAlignment: 6 * 25,
+		RoleTiming:    2*(45-16) - 2*5,
+		RoleVersion:   36,
+		RoleFormat:    31,
+	}
+	for role := RoleFinder; role <= RoleFormat; role++ {
+		if counts[role] != want[role] {
+			t.Errorf("role %d: got %d modules, want %d", role, counts[role], want[role])
+		}
+	}
+}
+
+func TestStyleSquareMatchesPlain(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.IntegerScaling = true
+	want, err := q.NRGBAImage(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Style = &Style{}
+	got, err := q.NRGBAImage(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Error("square styled image differs from the plain image")
+	}
+}
+
+func TestStyleShapes(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eye := color.NRGBA{0xc0, 0x10, 0x10, 0xff}
+	q.Style = &Style{
+		Modules: ModuleCircle,
+		Finder:  PatternStyle{Outer: PatternRounded, Inner: PatternCircle, InnerColor: eye},
+	}
+	const scale = 10
+	img, err := q.NRGBAImage(-scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qz := q.QuietZone
+	// Centre of the top left finder pattern
+	if got := img.NRGBAAt((qz+3)*scale+scale/2, (qz+3)*scale+scale/2); got != eye {
+		t.Errorf("got finder eye colour %v, want %v", got, eye)
+	}
+	// The rounded outer corner of the finder pattern is background
+	if got := img.NRGBAAt(qz*scale, qz*scale); got != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("got finder corner colour %v, want white", got)
+	}
+	roles := buildModuleRoles(q.version)
+	size := q.version.symbolSize()
+	checked := false
+	for y := 0; y < size && !checked; y++ {
+		for x := 0; x < size; x++ {
+			if roles[y*size+x] != RoleData || !q.symbol.get(x, y) {
+				continue
+			}
+			px, py := (x+qz)*scale, (y+qz)*scale
+			if got := img.NRGBAAt(px+scale/2, py+scale/2); got != (color.NRGBA{0, 0, 0, 0xff}) {
+				t.Errorf("module (%d, %d): got centre colour %v, want black", x, y, got)
+			}
+			if got := img.NRGBAAt(px, py); got != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+				t.Errorf("module (%d, %d): got corner colour %v, want white", x, y, got)
+			}
+			checked = true
+			break
+		}
+	}
+}
+
+// sliceColor is a color.Color that is not comparable with ==
+type sliceColor []uint8
+
+func (c sliceColor) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA{c[0], c[1], c[2], 0xff}.RGBA()
+}
+
+func TestStyleUncomparableColor(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eye := sliceColor{0xc0, 0x10, 0x10}
+	q.Style = &Style{Finder: PatternStyle{OuterColor: eye, InnerColor: sliceColor{0xc0, 0x10, 0x10}}}
+	q.encode()
+	groups := q.styledGroups()
+	// The finder patterns share one group, and the rest of the modules another
+	if len(groups) != 2 {
+		t.Errorf("got %d colour groups, want 2", len(groups))
+	}
+}
+
+func TestStyleVector(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Style = &Style{
+		Modules:   ModuleConnected,
+		Finder:    PatternStyle{Outer: PatternCircle, Inner: PatternCircle, OuterColor: color.RGBA{0, 0, 0x80, 0xff}},
+		Alignment: PatternStyle{Outer: PatternRounded},
+	}
+	b, err := q.SVG(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc svgDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Paths) != 2 || doc.Paths[0].Fill != "#000080" || doc.Paths[1].Fill != "#000000" {
+		t.Fatalf("got paths %+v, want the finder rings in navy and the rest in black", doc.Paths)
+	}
+	if !strings.Contains(doc.Paths[0].D, "C") {
+		t.Error("circular finder rings are not drawn with curves")
+	}
+	if _, err := q.PDF(&PDFOptions{ModuleSize: 1}); err != nil {
+		t.Error(err)
+	}
+	eps, err := q.EPS(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(eps), "eofill"); n != 2 {
+		t.Errorf("got %d filled paths in EPS, want 2", n)
+	}
+}
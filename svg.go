@@ -0,0 +1,217 @@
+package getqr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Id of the SVG clip path keeping the logo off alignment patterns
+const svgLogoClipID = "logo-clip"
+
+// Units of SVGOptions.Unit, the CSS absolute length units
+var svgUnits = map[string]bool{"": true, "px": true, "mm": true, "cm": true, "in": true, "pt": true, "pc": true}
+
+// SVGOptions configures SVG output. A nil *SVGOptions, like the zero value, draws each module as 1x1 user units
+type SVGOptions struct {
+	ModuleSize  float64 // Width and height of a module, in Unit. Zero means 1
+	Unit        string  // Unit of the width and height attributes: "px", "mm", "cm", "in", "pt" or "pc". Empty means user units (pixels)
+	Outline     bool    // Draw the dark modules as the outline of each dark area instead of merged horizontal runs
+	Title       string  // Text of a <title> element, for accessibility. Omitted if empty
+	Description string  // Text of a <desc> element, for accessibility. Omitted if empty
+}
+
+// Returns the QR Code as an SVG image
+// The dark modules are drawn as a single path, using ForegroundColor. A transparent BackgroundColor omits the background
+// If Style is set, the modules are drawn in the style, with a path for each colour, and Outline is ignored
+// A logo is embedded as a PNG image, see SetLogo(). A Fill is defined as an SVG gradient or pattern
+func (q *QRCode) SVG(opts *SVGOptions) ([]byte, error) {
+	var b bytes.Buffer
+	err := q.WriteSVG(&b, opts)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Writes the QR Code as an SVG image to out. See the documentation for SVG()
+func (q *QRCode) WriteSVG(out io.Writer, opts *SVGOptions) error {
+	if opts == nil {
+		opts = &SVGOptions{}
+	}
+	moduleSize, err := svgModuleSize(opts)
+	if err != nil {
+		return err
+	}
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	// Build QR code
+	q.encode()
+	s := q.symbol
+	quietZoneSize := s.quietZoneSize
+	size := s.symbolSize + 2*quietZoneSize
+	w := bufio.NewWriter(out)
+	writeSVGStart(w, float64(size), float64(size), moduleSize, opts)
+	if _, _, _, a := q.BackgroundColor.RGBA(); a != 0 {
+		fmt.Fprintf(w, `<rect width="%d" height="%d"%s/>`+"\n", size, size, svgFill(q.BackgroundColor))
+	}
+	if err := q.writeSVGModules(w, quietZoneSize, opts.Outline); err != nil {
+		return err
+	}
+	w.WriteString("</svg>\n")
+	return w.Flush()
+}
+
+// Writes the elements drawing the dark modules, and the logo if there is one
+func (q *QRCode) writeSVGModules(w *bufio.Writer, quietZoneSize int, outline bool) error {
+	s := q.symbol
+	if q.ModuleColor != nil {
+		// One path per colour
+		for _, g := range q.moduleColorGroups() {
+			fmt.Fprintf(w, `<path%s d="`, svgFill(g.color))
+			for _, r := range g.runs {
+				fmt.Fprintf(w, "M%d %dh%dv1h-%dz", r.x+quietZoneSize, r.y+quietZoneSize, r.n, r.n)
+			}
+			w.WriteString(`"/>` + "\n")
+		}
+		return q.writeSVGLogo(w, quietZoneSize)
+	}
+	foreground := svgFill(q.ForegroundColor)
+	if q.Fill != nil {
+		w.WriteString("<defs>\n")
+		if err := q.Fill.writeSVG(w, float64(quietZoneSize), float64(quietZoneSize), float64(s.symbolSize)); err != nil {
+			return err
+		}
+		w.WriteString("</defs>\n")
+		foreground = ` fill="url(#` + svgFillID + `)"`
+	}
+	if q.Style != nil {
+		// One path per colour, with the holes of the patterns cut out
+		f := pathFormatter{w: w, dx: float64(quietZoneSize), dy: float64(quietZoneSize), ops: svgPathOperators}
+		for _, g := range q.styledGroups() {
+			fill := svgFill(g.color)
+			if g.foreground {
+				fill = foreground
+			}
+			fmt.Fprintf(w, `<path%s fill-rule="evenodd" d="`, fill)
+			g.path(&f)
+			w.WriteString(`"/>` + "\n")
+		}
+	} else {
+		fmt.Fprintf(w, `<path%s d="`, foreground)
+		if outline {
+			s.outline(func(points []image.Point) {
+				p := points[0]
+				fmt.Fprintf(w, "M%d %d", p.X+quietZoneSize, p.Y+quietZoneSize)
+				for _, next := range points[1:] {
+					if next.Y == p.Y {
+						fmt.Fprintf(w, "h%d", next.X-p.X)
+					} else {
+						fmt.Fprintf(w, "v%d", next.Y-p.Y)
+					}
+					p = next
+				}
+				w.WriteString("z")
+			})
+		} else {
+			for y := 0; y < s.symbolSize; y++ {
+				s.forEachRun(y, func(x int, n int) {
+					fmt.Fprintf(w, "M%d %dh%dv1h-%dz", x+quietZoneSize, y+quietZoneSize, n, n)
+				})
+			}
+		}
+		w.WriteString(`"/>` + "\n")
+	}
+	return q.writeSVGLogo(w, quietZoneSize)
+}
+
+// Writes the logo, if there is one, as an embedded PNG image
+func (q *QRCode) writeSVGLogo(w *bufio.Writer, quietZoneSize int) error {
+	if q.logo == nil {
+		return nil
+	}
+	p := q.logo
+	qz := float64(quietZoneSize)
+	clip := ""
+	if len(p.alignment) > 0 {
+		// Alignment patterns show through the logo: the clip path is the logo less their modules
+		fmt.Fprintf(w, `<clipPath id="%s"><path clip-rule="evenodd" d="M%s %sh%sv%sh-%sz`, svgLogoClipID,
+			formatNumber(p.x+qz), formatNumber(p.y+qz), formatNumber(p.w), formatNumber(p.h), formatNumber(p.w))
+		for _, a := range p.alignment {
+			fmt.Fprintf(w, "M%d %dh1v1h-1z", a.X+quietZoneSize, a.Y+quietZoneSize)
+		}
+		w.WriteString(`"/></clipPath>` + "\n")
+		clip = ` clip-path="url(#` + svgLogoClipID + `)"`
+	}
+	fmt.Fprintf(w, `<image x="%s" y="%s" width="%s" height="%s" preserveAspectRatio="none"%s href="data:image/png;base64,`,
+		formatNumber(p.x+qz), formatNumber(p.y+qz), formatNumber(p.w), formatNumber(p.h), clip)
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if err := encodePNG(enc, p.image); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	w.WriteString(`"/>` + "\n")
+	return nil
+}
+
+// Returns the module size set by opts, checking it and the unit
+func svgModuleSize(opts *SVGOptions) (float64, error) {
+	if !svgUnits[opts.Unit] {
+		return 0, fmt.Errorf("invalid SVG unit %q", opts.Unit)
+	}
+	if opts.ModuleSize < 0 {
+		return 0, fmt.Errorf("invalid SVG module size %v", opts.ModuleSize)
+	} else if opts.ModuleSize == 0 {
+		return 1, nil
+	}
+	return opts.ModuleSize, nil
+}
+
+// Writes the XML declaration and the svg start tag for an image width x height user units,
+// followed by the title and description of opts
+func writeSVGStart(w *bufio.Writer, width, height, moduleSize float64, opts *SVGOptions) {
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" version="1.1" width="%s" height="%s" viewBox="0 0 %s %s" shape-rendering="crispEdges"`,
+		formatNumber(width*moduleSize)+opts.Unit, formatNumber(height*moduleSize)+opts.Unit, formatNumber(width), formatNumber(height))
+	if opts.Title != "" || opts.Description != "" {
+		w.WriteString(` role="img"`)
+	}
+	w.WriteString(">\n")
+	writeSVGText(w, "title", opts.Title)
+	writeSVGText(w, "desc", opts.Description)
+}
+
+// Writes an element named name containing text, escaped. Nothing is written if text is empty
+func writeSVGText(w *bufio.Writer, name string, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(w, "<%s>", name)
+	xml.EscapeText(w, []byte(text))
+	fmt.Fprintf(w, "</%s>\n", name)
+}
+
+// Returns the fill and fill-opacity attributes for c
+func svgFill(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	fill := fmt.Sprintf(` fill="#%02x%02x%02x"`, n.R, n.G, n.B)
+	if n.A != 0xff {
+		fill += ` fill-opacity="` + formatNumber(float64(n.A)/0xff) + `"`
+	}
+	return fill
+}
+
+// Returns v formatted compactly, with at most 4 decimal places
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64)
+}
@@ -0,0 +1,152 @@
+package getqr
+
+import (
+	"encoding/xml"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+type svgDocument struct {
+	Width  string `xml:"width,attr"`
+	Height string `xml:"height,attr"`
+	Title  string `xml:"title"`
+	Desc   string `xml:"desc"`
+	Rects  []struct {
+		Fill string `xml:"fill,attr"`
+	} `xml:"rect"`
+	Paths []struct {
+		Fill string `xml:"fill,attr"`
+		D    string `xml:"d,attr"`
+	} `xml:"path"`
+}
+
+// Parses an SVG path consisting of M, h, v and z commands into polygons
+func parseSVGPath(t *testing.T, d string) [][]image.Point {
+	fields := strings.FieldsFunc(strings.NewReplacer("M", " M ", "h", " h ", "v", " v ", "z", " z ").Replace(d), unicode.IsSpace)
+	var polygons [][]image.Point
+	var p image.Point
+	number := func(i int) int {
+		v, err := strconv.Atoi(fields[i])
+		if err != nil {
+			t.Fatalf("invalid path %q: %v", d, err)
+		}
+		return v
+	}
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "M":
+			p = image.Point{number(i + 1), number(i + 2)}
+			polygons = append(polygons, []image.Point{p})
+			i += 2
+		case "h":
+			p.X += number(i + 1)
+			polygons[len(polygons)-1] = append(polygons[len(polygons)-1], p)
+			i++
+		case "v":
+			p.Y += number(i + 1)
+			polygons[len(polygons)-1] = append(polygons[len(polygons)-1], p)
+			i++
+		case "z":
+		default:
+			t.Fatalf("invalid path command %q", fields[i])
+		}
+	}
+	return polygons
+}
+
+// Returns a bitmap of the module centres inside polygons, using the nonzero fill rule
+func rasterisePolygons(polygons [][]image.Point, size int) [][]bool {
+	bitmap := make([][]bool, size)
+	for y := range bitmap {
+		bitmap[y] = make([]bool, size)
+		for x := range bitmap[y] {
+			// Winding number of a ray from the module centre towards +x
+			winding := 0
+			for _, polygon := range polygons {
+				for i, a := range polygon {
+					b := polygon[(i+1)%len(polygon)]
+					if a.X != b.X || a.X <= x {
+						continue
+					}
+					if a.Y <= y && b.Y > y {
+						winding++
+					} else if b.Y <= y && a.Y > y {
+						winding--
+					}
+				}
+			}
+			bitmap[y][x] = winding != 0
+		}
+	}
+	return bitmap
+}
+
+func TestSVGMatchesBitmap(t *testing.T) {
+	for _, content := range []string{"getqr", strings.Repeat("https://github.com/pchchv/getqr ", 20)} {
+		for _, outline := range []bool{false, true} {
+			q, err := New(content, Medium)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := q.SVG(&SVGOptions{Outline: outline})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc svgDocument
+			if err := xml.Unmarshal(b, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if len(doc.Paths) != 1 || doc.Paths[0].Fill != "#000000" {
+				t.Fatalf("outline %v: got paths %+v, want a single black path", outline, doc.Paths)
+			}
+			bitmap := q.Bitmap()
+			got := rasterisePolygons(parseSVGPath(t, doc.Paths[0].D), len(bitmap))
+			for y := range bitmap {
+				for x := range bitmap[y] {
+					if got[y][x] != bitmap[y][x] {
+						t.Fatalf("outline %v: module (%d, %d) is %v, want %v", outline, x, y, got[y][x], bitmap[y][x])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSVGOptions(t *testing.T) {
+	q, err := New("getqr", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.BackgroundColor = color.Transparent
+	q.QuietZone = 2
+	b, err := q.SVG(&SVGOptions{ModuleSize: 0.5, Unit: "mm", Title: "Scan <me>", Description: "Link & more"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc svgDocument
+	if err := xml.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	// Version 1 is 21 modules wide, plus 2 quiet zones of 2 modules
+	if doc.Width != "12.5mm" || doc.Height != "12.5mm" {
+		t.Errorf("got size %s x %s, want 12.5mm x 12.5mm", doc.Width, doc.Height)
+	}
+	if doc.Title != "Scan <me>" || doc.Desc != "Link & more" {
+		t.Errorf("got title %q and desc %q", doc.Title, doc.Desc)
+	}
+	if len(doc.Rects) != 0 {
+		t.Errorf("got %d background rects with a transparent background, want 0", len(doc.Rects))
+	}
+	if _, err := q.SVG(&SVGOptions{ModuleSize: -1}); err == nil {
+		t.Error("SVG with a negative module size succeeded, want error")
+	}
+	for _, unit := range []string{"em", "%", `mm" onload="alert(1)`} {
+		if _, err := q.SVG(&SVGOptions{Unit: unit}); err == nil {
+			t.Errorf("SVG with unit %q succeeded, want error", unit)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package getqr
 
+import "math/bits"
+
 type symbol struct {
-	module        [][]bool // Value of module at [y][x]. True is set
-	isUsed        [][]bool // True if the module at [y][x] is used (to either true or false). Used to identify unused modules
+	module        []uint64 // Packed module values, row-major. Bit x%64 of word y*stride+x/64 is the module at (x, y). Set is true
+	isUsed        []uint64 // Packed flags, laid out as module. Set if the module at (x, y) is used (to either true or false)
+	stride        int      // Number of 64-bit words per row
 	size          int      // Combined width/height of the symbol and quiet zones. size = symbolSize + 2*quietZoneSize
 	symbolSize    int      // Width/height of the symbol only
 	quietZoneSize int      // Width/height of a single quiet zone
+	cols          []uint64 // Scratch space for columns()
 }
 
 // Constants used to weight penalty calculations. Specified by ISO/IEC 18004:2006
@@ -17,24 +21,51 @@ const (
 )
 
 // Constructs a symbol of size size*size, with a border of quietZoneSize
+// Only the symbol itself is stored: the quiet zone is always light and is added by bitmap()
 func newSymbol(size int, quietZoneSize int) *symbol {
-	var m symbol
-	m.module = make([][]bool, size+2*quietZoneSize)
-	m.isUsed = make([][]bool, size+2*quietZoneSize)
-	for i := range m.module {
-		m.module[i] = make([]bool, size+2*quietZoneSize)
-		m.isUsed[i] = make([]bool, size+2*quietZoneSize)
-	}
+	m := &symbol{}
+	m.reset(size, quietZoneSize)
+	return m
+}
+
+// Clears the symbol and resizes it to size*size, with a border of quietZoneSize
+// The existing storage is reused if it is large enough
+func (m *symbol) reset(size int, quietZoneSize int) {
+	m.stride = (size + 63) / 64
+	m.module = resizeWords(m.module, size*m.stride)
+	m.isUsed = resizeWords(m.isUsed, size*m.stride)
 	m.size = size + 2*quietZoneSize
 	m.symbolSize = size
 	m.quietZoneSize = quietZoneSize
-	return &m
+}
+
+// Makes the symbol a copy of other. The existing storage is reused if it is large enough
+func (m *symbol) copyFrom(other *symbol) {
+	m.module = append(m.module[:0], other.module...)
+	m.isUsed = append(m.isUsed[:0], other.isUsed...)
+	m.stride = other.stride
+	m.size = other.size
+	m.symbolSize = other.symbolSize
+	m.quietZoneSize = other.quietZoneSize
+}
+
+// Returns a copy of the symbol
+func (m *symbol) clone() *symbol {
+	c := &symbol{}
+	c.copyFrom(m)
+	return c
 }
 
 // Sets the module at (x, y) to v
 func (m *symbol) set(x int, y int, v bool) {
-	m.module[y+m.quietZoneSize][x+m.quietZoneSize] = v
-	m.isUsed[y+m.quietZoneSize][x+m.quietZoneSize] = true
+	i := y*m.stride + x/64
+	bit := uint64(1) << uint(x%64)
+	if v {
+		m.module[i] |= bit
+	} else {
+		m.module[i] &^= bit
+	}
+	m.isUsed[i] |= bit
 }
 
 // Sets a 2D array of modules, starting at (x, y)
@@ -48,33 +79,35 @@ func (m *symbol) set2dPattern(x int, y int, v [][]bool) {
 
 // Returns true if the module at (x, y) has not been set (to either true or false)
 func (m *symbol) empty(x int, y int) bool {
-	return !m.isUsed[y+m.quietZoneSize][x+m.quietZoneSize]
+	return m.isUsed[y*m.stride+x/64]&(1<<uint(x%64)) == 0
 }
 
 // Returns the number of empty modules. Initially numEmptyModules is symbolSize * symbolSize
 // After every module has been set (to either true or false), the number of empty modules is zero
 func (m *symbol) numEmptyModules() int {
-	var count int
-	for y := 0; y < m.symbolSize; y++ {
-		for x := 0; x < m.symbolSize; x++ {
-			if !m.isUsed[y+m.quietZoneSize][x+m.quietZoneSize] {
-				count++
-			}
-		}
-	}
-	return count
+	return m.symbolSize*m.symbolSize - popCount(m.isUsed)
 }
 
 // get returns the module value at (x, y)
 func (m *symbol) get(x int, y int) (v bool) {
-	v = m.module[y+m.quietZoneSize][x+m.quietZoneSize]
+	v = m.module[y*m.stride+x/64]&(1<<uint(x%64)) != 0
 	return
 }
 
+// Returns the module value at (x, y) of the bitmap, i.e. counting the quiet zone. Modules in the quiet zone are never set
+func (m *symbol) bitmapAt(x int, y int) bool {
+	x -= m.quietZoneSize
+	y -= m.quietZoneSize
+	if x < 0 || y < 0 || x >= m.symbolSize || y >= m.symbolSize {
+		return false
+	}
+	return m.get(x, y)
+}
+
 // Returns a pictorial representation of the symbol, suitable for printing in a TTY
 func (m *symbol) string() string {
 	var result string
-	for _, row := range m.module {
+	for _, row := range m.bitmap() {
 		for _, value := range row {
 			switch value {
 			case true:
@@ -89,51 +122,49 @@ func (m *symbol) string() string {
 	return result
 }
 
+// Returns the symbol transposed, i.e. the packed module values in column-major order
+// Row x of the result holds column x of the symbol, so the column penalties can reuse the row algorithms
+// The result is only valid until the next call
+func (m *symbol) columns() []uint64 {
+	m.cols = resizeWords(m.cols, len(m.module))
+	cols := m.cols
+	for y := 0; y < m.symbolSize; y++ {
+		row := m.module[y*m.stride : (y+1)*m.stride]
+		bit := uint64(1) << uint(y%64)
+		for w, word := range row {
+			for word != 0 {
+				x := w*64 + bits.TrailingZeros64(word)
+				cols[x*m.stride+y/64] |= bit
+				word &= word - 1
+			}
+		}
+	}
+	return cols
+}
+
 // Returns the penalty score of the symbol
 // The penalty score consists of the sum of the four individual penalty types
 func (m *symbol) penaltyScore() int {
-	return m.penalty1() + m.penalty2() + m.penalty3() + m.penalty4()
+	cols := m.columns()
+	return m.penalty1(cols) + m.penalty2() + m.penalty3(cols) + m.penalty4()
 }
 
 // Returns the penalty score for "adjacent modules in row/column with same colour"
+// cols is the transposed symbol, as returned by columns()
 // The numbers of adjacent matching modules and scores are:
 // 0-5: score = 0
 // 6+ : score = penaltyWeight1 + (numAdjacentModules - 5)
-func (m *symbol) penalty1() int {
+func (m *symbol) penalty1(cols []uint64) int {
 	penalty := 0
-	for x := 0; x < m.symbolSize; x++ {
-		lastValue := m.get(x, 0)
-		count := 1
-		for y := 1; y < m.symbolSize; y++ {
-			v := m.get(x, y)
-			if v != lastValue {
-				count = 1
-				lastValue = v
-			} else {
-				count++
-				if count == 6 {
-					penalty += penaltyWeight1 + 1
-				} else if count > 6 {
-					penalty++
-				}
-			}
-		}
-	}
-	for y := 0; y < m.symbolSize; y++ {
-		lastValue := m.get(0, y)
-		count := 1
-		for x := 1; x < m.symbolSize; x++ {
-			v := m.get(x, y)
-			if v != lastValue {
-				count = 1
-				lastValue = v
-			} else {
-				count++
-				if count == 6 {
-					penalty += penaltyWeight1 + 1
-				} else if count > 6 {
-					penalty++
+	for _, grid := range [][]uint64{m.module, cols} {
+		for y := 0; y < m.symbolSize; y++ {
+			row := grid[y*m.stride : (y+1)*m.stride]
+			for x := 0; x < m.symbolSize; {
+				n := runLength(row, x, m.symbolSize)
+				if n >= 6 {
+					penalty += penaltyWeight1 + n - 5
 				}
+				x += n
 			}
 		}
 	}
@@ -142,17 +173,23 @@ func (m *symbol) penalty1() int {
 
 // penalty2 returns the penalty score for "block of modules in the same colour"
 // m*n: score = penaltyWeight2 * (m-1) * (n-1)
+// Each 2x2 block of matching modules is counted via the bitwise equality of two adjacent rows
 func (m *symbol) penalty2() int {
 	penalty := 0
+	last := m.symbolSize - 1 // Number of 2x2 blocks per row
 	for y := 1; y < m.symbolSize; y++ {
-		for x := 1; x < m.symbolSize; x++ {
-			topLeft := m.get(x-1, y-1)
-			above := m.get(x, y-1)
-			left := m.get(x-1, y)
-			current := m.get(x, y)
-			if current == left && current == above && current == topLeft {
-				penalty++
+		above := m.module[(y-1)*m.stride : y*m.stride]
+		current := m.module[y*m.stride : (y+1)*m.stride]
+		for w := 0; w < m.stride; w++ {
+			// Bit x is set if the module at (x, y) matches its upper, right and upper right neighbours
+			vertical := ^(above[w] ^ current[w])
+			verticalRight := ^(shiftedWord(above, w) ^ shiftedWord(current, w))
+			horizontal := ^(current[w] ^ shiftedWord(current, w))
+			blocks := vertical & verticalRight & horizontal
+			if remaining := last - w*64; remaining < 64 {
+				blocks &= 1<<uint(remaining) - 1
 			}
+			penalty += bits.OnesCount64(blocks)
 		}
 	}
 	return penalty * penaltyWeight2
@@ -160,47 +197,28 @@ func (m *symbol) penalty2() int {
 
 // Returns the penalty score for "1:1:3:1:1 ratio (dark:light:dark:light:dark) pattern in row/column,
 // preceded or followed by light area 4 modules wide"
+// cols is the transposed symbol, as returned by columns()
 // Existence of the pattern scores penaltyWeight3
-func (m *symbol) penalty3() int {
+func (m *symbol) penalty3(cols []uint64) int {
 	penalty := 0
-	for y := 0; y < m.symbolSize; y++ {
-		var bitBuffer int16 = 0x00
-		for x := 0; x < m.symbolSize; x++ {
-			bitBuffer <<= 1
-			if v := m.get(x, y); v {
-				bitBuffer |= 1
-			}
-			switch bitBuffer & 0x7ff {
-			// 0b000 0101 1101 or 0b10111010000
-			// 0x05d           or 0x5d0
-			case 0x05d, 0x5d0:
-				penalty += penaltyWeight3
-				bitBuffer = 0xFF
-			default:
-				if x == m.symbolSize-1 && (bitBuffer&0x7f) == 0x5d {
-					penalty += penaltyWeight3
-					bitBuffer = 0xFF
-				}
-			}
-		}
-	}
-	for x := 0; x < m.symbolSize; x++ {
-		var bitBuffer int16 = 0x00
+	for _, grid := range [][]uint64{m.module, cols} {
 		for y := 0; y < m.symbolSize; y++ {
-			bitBuffer <<= 1
-			if v := m.get(x, y); v {
-				bitBuffer |= 1
-			}
-			switch bitBuffer & 0x7ff {
-			// 0b000 0101 1101 or 0b10111010000
-			// 0x05d           or 0x5d0
-			case 0x05d, 0x5d0:
-				penalty += penaltyWeight3
-				bitBuffer = 0xFF
-			default:
-				if y == m.symbolSize-1 && (bitBuffer&0x7f) == 0x5d {
+			row := grid[y*m.stride : (y+1)*m.stride]
+			var bitBuffer int16 = 0x00
+			for x := 0; x < m.symbolSize; x++ {
+				bitBuffer <<= 1
+				bitBuffer |= int16(row[x/64] >> uint(x%64) & 1)
+				switch bitBuffer & 0x7ff {
+				// 0b000 0101 1101 or 0b10111010000
+				// 0x05d           or 0x5d0
+				case 0x05d, 0x5d0:
 					penalty += penaltyWeight3
 					bitBuffer = 0xFF
+				default:
+					if x == m.symbolSize-1 && (bitBuffer&0x7f) == 0x5d {
+						penalty += penaltyWeight3
+						bitBuffer = 0xFF
+					}
 				}
 			}
 		}
@@ -211,14 +229,7 @@ func (m *symbol) penalty3() int {
 // Returns the penalty score
 func (m *symbol) penalty4() int {
 	numModules := m.symbolSize * m.symbolSize
-	numDarkModules := 0
-	for x := 0; x < m.symbolSize; x++ {
-		for y := 0; y < m.symbolSize; y++ {
-			if v := m.get(x, y); v {
-				numDarkModules++
-			}
-		}
-	}
+	numDarkModules := popCount(m.module)
 	numDarkModuleDeviation := numModules/2 - numDarkModules
 	if numDarkModuleDeviation < 0 {
 		numDarkModuleDeviation *= -1
@@ -228,9 +239,85 @@ func (m *symbol) penalty4() int {
 
 // Returns the entire symbol, including the quiet zone
 func (m *symbol) bitmap() [][]bool {
-	module := make([][]bool, len(m.module))
-	for i := range m.module {
-		module[i] = m.module[i][:]
+	module := make([][]bool, m.size)
+	for i := range module {
+		module[i] = make([]bool, m.size)
+	}
+	for y := 0; y < m.symbolSize; y++ {
+		row := module[y+m.quietZoneSize][m.quietZoneSize:]
+		for x := 0; x < m.symbolSize; x++ {
+			row[x] = m.get(x, y)
+		}
 	}
 	return module
 }
+
+// Calls fn for each horizontal run of set modules in row y, with the x coordinate of its first module and its length
+// Coordinates exclude the quiet zone
+func (m *symbol) forEachRun(y int, fn func(x int, n int)) {
+	row := m.module[y*m.stride : (y+1)*m.stride]
+	for x := 0; x < m.symbolSize; {
+		n := runLength(row, x, m.symbolSize)
+		if m.get(x, y) {
+			fn(x, n)
+		}
+		x += n
+	}
+}
+
+// Returns the number of consecutive modules in row, starting at x, with the same value as the module at x
+// The run is limited to the first n modules of the row
+func runLength(row []uint64, x int, n int) int {
+	var value uint64 // All ones if the run is of set modules
+	if row[x/64]&(1<<uint(x%64)) != 0 {
+		value = ^uint64(0)
+	}
+	length := 0
+	for i := x; i < n; {
+		offset := uint(i % 64)
+		// Set bits mark modules that differ from the start of the run
+		diff := (row[i/64] ^ value) >> offset
+		count := 64 - int(offset)
+		if diff != 0 {
+			count = bits.TrailingZeros64(diff)
+			length += count
+			break
+		}
+		length += count
+		i += count
+	}
+	if length > n-x {
+		length = n - x
+	}
+	return length
+}
+
+// Returns word w of row shifted right by one module, so bit x holds the module at x+1
+func shiftedWord(row []uint64, w int) uint64 {
+	word := row[w] >> 1
+	if w+1 < len(row) {
+		word |= row[w+1] << 63
+	}
+	return word
+}
+
+// Returns words resized to n zeroed words, reusing its storage if the capacity is sufficient
+func resizeWords(words []uint64, n int) []uint64 {
+	if cap(words) < n {
+		return make([]uint64, n)
+	}
+	words = words[:n]
+	for i := range words {
+		words[i] = 0
+	}
+	return words
+}
+
+// Returns the number of set bits in words
+func popCount(words []uint64) int {
+	count := 0
+	for _, w := range words {
+		count += bits.OnesCount64(w)
+	}
+	return count
+}
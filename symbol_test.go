@@ -0,0 +1,152 @@
+package getqr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	bitset "github.com/pchchv/getqr/bitset"
+)
+
+// Returns the interleaved data and error correction bits of a QR Code with the given version
+func testEncodedData(t testing.TB, version int) (qrCodeVersion, *bitset.Bitset) {
+	q, err := NewWithForcedVersion(strings.Repeat("getqr", 2*version), version, Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.addTerminatorBits(q.version.numTerminatorBitsRequired(q.data.Len()))
+	q.addPadding()
+	return q.version, q.encodeBlocks()
+}
+
+// Returns the penalty score of m, computed module by module
+func referencePenaltyScore(m *symbol) int {
+	n := m.symbolSize
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return m.get(y, x)
+		}
+		return m.get(x, y)
+	}
+	penalty := 0
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			count := 1
+			var bitBuffer int16 = 0x00
+			for x := 0; x < n; x++ {
+				v := at(x, y, transpose)
+				if x > 0 && v == at(x-1, y, transpose) {
+					count++
+					if count == 6 {
+						penalty += penaltyWeight1 + 1
+					} else if count > 6 {
+						penalty++
+					}
+				} else {
+					count = 1
+				}
+				bitBuffer <<= 1
+				if v {
+					bitBuffer |= 1
+				}
+				switch bitBuffer & 0x7ff {
+				case 0x05d, 0x5d0:
+					penalty += penaltyWeight3
+					bitBuffer = 0xFF
+				default:
+					if x == n-1 && (bitBuffer&0x7f) == 0x5d {
+						penalty += penaltyWeight3
+						bitBuffer = 0xFF
+					}
+				}
+			}
+		}
+	}
+	numDarkModules := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			v := m.get(x, y)
+			if v {
+				numDarkModules++
+			}
+			if x > 0 && y > 0 && v == m.get(x-1, y) && v == m.get(x, y-1) && v == m.get(x-1, y-1) {
+				penalty += penaltyWeight2
+			}
+		}
+	}
+	deviation := n*n/2 - numDarkModules
+	if deviation < 0 {
+		deviation *= -1
+	}
+	return penalty + penaltyWeight4*(deviation/(n*n/20))
+}
+
+func TestPenaltyScore(t *testing.T) {
+	for version := 1; version <= 40; version++ {
+		v, data := testEncodedData(t, version)
+		for mask := 0; mask < 8; mask++ {
+			s, err := buildRegularSymbol(v, mask, data, v.quietZoneSize())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := s.numEmptyModules(); n != 0 {
+				t.Errorf("version %d mask %d: numEmptyModules is %d, want 0", version, mask, n)
+			}
+			got := s.penaltyScore()
+			want := referencePenaltyScore(s)
+			if got != want {
+				t.Errorf("version %d mask %d: penaltyScore() = %d, want %d", version, mask, got, want)
+			}
+		}
+	}
+}
+
+func TestBitmap(t *testing.T) {
+	v, data := testEncodedData(t, 7)
+	s, err := buildRegularSymbol(v, 0, data, v.quietZoneSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := s.bitmap()
+	if len(bitmap) != s.size {
+		t.Fatalf("bitmap has %d rows, want %d", len(bitmap), s.size)
+	}
+	for y, row := range bitmap {
+		for x, value := range row {
+			sx, sy := x-s.quietZoneSize, y-s.quietZoneSize
+			want := sx >= 0 && sy >= 0 && sx < s.symbolSize && sy < s.symbolSize && s.get(sx, sy)
+			if value != want {
+				t.Fatalf("bitmap[%d][%d] = %v, want %v", y, x, value, want)
+			}
+		}
+	}
+}
+
+func BenchmarkPenaltyScore(b *testing.B) {
+	for _, version := range []int{10, 20, 30, 40} {
+		v, data := testEncodedData(b, version)
+		s, err := buildRegularSymbol(v, 0, data, v.quietZoneSize())
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("version%d", version), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.penaltyScore()
+			}
+		})
+	}
+}
+
+func BenchmarkBuildRegularSymbol(b *testing.B) {
+	for _, version := range []int{10, 20, 30, 40} {
+		v, data := testEncodedData(b, version)
+		b.Run(fmt.Sprintf("version%d", version), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := buildRegularSymbol(v, i%8, data, v.quietZoneSize()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
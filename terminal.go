@@ -0,0 +1,201 @@
+package getqr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+)
+
+// Largest payload of a kitty graphics protocol escape sequence, in base64 bytes
+const kittyChunkSize = 4096
+
+// TerminalCapabilities describes how a terminal can display a QR Code, see DetectTerminal()
+type TerminalCapabilities struct {
+	TTY       bool // The output is a terminal, rather than a file or pipe
+	TrueColor bool // ANSI 24-bit colours are supported, see ANSIString()
+	Sixel     bool // Sixel graphics are supported, see WriteSixel()
+	Kitty     bool // The kitty graphics protocol is supported, see WriteKitty()
+}
+
+// Detects the capabilities of the terminal that f writes to, e.g. os.Stdout
+// A terminal cannot be asked without reading its replies from the input, so the capabilities are guessed from the environment:
+// COLORTERM for 24-bit colours, and TERM, TERM_PROGRAM and KITTY_WINDOW_ID for graphics. Nothing is supported if f is not a terminal
+func DetectTerminal(f *os.File) TerminalCapabilities {
+	info, err := f.Stat()
+	tty := err == nil && info.Mode()&os.ModeCharDevice != 0
+	return detectTerminal(tty, os.Getenv)
+}
+
+// Returns the capabilities of a terminal with the environment getenv. See DetectTerminal()
+func detectTerminal(tty bool, getenv func(string) string) TerminalCapabilities {
+	if !tty {
+		return TerminalCapabilities{}
+	}
+	term := getenv("TERM")
+	program := getenv("TERM_PROGRAM")
+	colorTerm := getenv("COLORTERM")
+	c := TerminalCapabilities{TTY: true}
+	c.Kitty = getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") ||
+		program == "WezTerm" || program == "ghostty"
+	switch {
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "mlterm"), strings.HasPrefix(term, "foot"),
+		strings.HasPrefix(term, "yaft"), program == "iTerm.app", program == "WezTerm":
+		c.Sixel = true
+	}
+	c.TrueColor = colorTerm == "truecolor" || colorTerm == "24bit" || c.Kitty || c.Sixel
+	return c
+}
+
+// Produces a multi-line string drawing the QR Code with half block characters, two modules per character,
+// in ANSI 24-bit colours set to ForegroundColor and BackgroundColor, so it reads the same on light and dark terminals
+// Transparent colours are shown on white, and nil ones as black on white. Style, Fill and ModuleColor are ignored
+func (q *QRCode) ANSIString() string {
+	bits := q.Bitmap()
+	foreground, background := q.ForegroundColor, q.BackgroundColor
+	if foreground == nil {
+		foreground = color.Black
+	}
+	if background == nil {
+		background = color.White
+	}
+	fg := compositeOver(foreground, color.White)
+	bg := compositeOver(background, color.White)
+	code := func(layer int, v bool) string {
+		c := bg
+		if v {
+			c = fg
+		}
+		return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, c.R, c.G, c.B)
+	}
+	var buf bytes.Buffer
+	for y := 0; y < len(bits); y += 2 {
+		// The upper module is the foreground of ▀ and the lower one its background
+		upper, lower := "", ""
+		for x := range bits[y] {
+			// An odd last row is drawn over the background
+			bottom := y+1 < len(bits) && bits[y+1][x]
+			if c := code(38, bits[y][x]); c != upper {
+				upper = c
+				buf.WriteString(c)
+			}
+			if c := code(48, bottom); c != lower {
+				lower = c
+				buf.WriteString(c)
+			}
+			buf.WriteString("▀")
+		}
+		buf.WriteString("\x1b[0m\n")
+	}
+	return buf.String()
+}
+
+// Writes the QR Code to out in ANSI 24-bit colours. See the documentation for ANSIString()
+// An error is returned if BackgroundColor and ForegroundColor are unset or too similar to tell apart
+func (q *QRCode) WriteANSI(out io.Writer) error {
+	if err := validateColorPair(q.BackgroundColor, q.ForegroundColor); err != nil {
+		return err
+	}
+	_, err := io.WriteString(out, q.ANSIString())
+	return err
+}
+
+// Writes the QR Code to out as a sixel image, for terminals supporting sixel graphics
+// See the documentation for Image() for the meaning of size. Transparent colours are shown on white
+// Styled images with more than 256 colours are reduced to a palette of 216 colours
+func (q *QRCode) WriteSixel(out io.Writer, size int) error {
+	if err := q.validateContrast(); err != nil {
+		return err
+	}
+	img := palettedImage(q.Image(size))
+	w := bufio.NewWriter(out)
+	b := img.Rect
+	fmt.Fprintf(w, "\x1bPq\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, c := range img.Palette {
+		rgb := compositeOver(c, color.White)
+		fmt.Fprintf(w, "#%d;2;%d;%d;%d", i, int(rgb.R)*100/0xff, int(rgb.G)*100/0xff, int(rgb.B)*100/0xff)
+	}
+	sixels := make([]byte, b.Dx())
+	for y := b.Min.Y; y < b.Max.Y; y += 6 {
+		if y > b.Min.Y {
+			w.WriteByte('-')
+		}
+		// Colours used in this band of six rows
+		var used [256]bool
+		for i := 0; i < 6 && y+i < b.Max.Y; i++ {
+			for _, c := range img.Pix[(y+i-b.Min.Y)*img.Stride:][:b.Dx()] {
+				used[c] = true
+			}
+		}
+		first := true
+		for c := range img.Palette {
+			if !used[c] {
+				continue
+			}
+			for x := range sixels {
+				var bits byte
+				for i := 0; i < 6 && y+i < b.Max.Y; i++ {
+					if img.Pix[(y+i-b.Min.Y)*img.Stride+x] == uint8(c) {
+						bits |= 1 << uint(i)
+					}
+				}
+				sixels[x] = '?' + bits
+			}
+			if !first {
+				// Back to the start of the band
+				w.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(w, "#%d", c)
+			writeSixelRuns(w, sixels)
+		}
+	}
+	w.WriteString("\x1b\\")
+	return w.Flush()
+}
+
+// Writes sixels, compressing runs of more than three of the same sixel
+func writeSixelRuns(w *bufio.Writer, sixels []byte) {
+	for x := 0; x < len(sixels); {
+		n := 1
+		for x+n < len(sixels) && sixels[x+n] == sixels[x] {
+			n++
+		}
+		if n > 3 {
+			fmt.Fprintf(w, "!%d%c", n, sixels[x])
+		} else {
+			w.Write(sixels[x : x+n])
+		}
+		x += n
+	}
+}
+
+// Writes the QR Code to out as a PNG image in kitty graphics protocol escape sequences,
+// for terminals supporting the protocol. See the documentation for Image() for the meaning of size
+func (q *QRCode) WriteKitty(out io.Writer, size int) error {
+	png, err := q.PNG(size)
+	if err != nil {
+		return err
+	}
+	payload := base64.StdEncoding.EncodeToString(png)
+	w := bufio.NewWriter(out)
+	for i := 0; i < len(payload); i += kittyChunkSize {
+		end := i + kittyChunkSize
+		more := 1
+		if end >= len(payload) {
+			end, more = len(payload), 0
+		}
+		w.WriteString("\x1b_G")
+		if i == 0 {
+			// Transmit and display a PNG image
+			w.WriteString("a=T,f=100,")
+		}
+		fmt.Fprintf(w, "m=%d;%s\x1b\\", more, payload[i:end])
+	}
+	w.WriteString("\n")
+	return w.Flush()
+}
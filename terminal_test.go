@@ -0,0 +1,253 @@
+package getqr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDetectTerminal(t *testing.T) {
+	tests := []struct {
+		tty  bool
+		env  map[string]string
+		want TerminalCapabilities
+	}{
+		{false, map[string]string{"COLORTERM": "truecolor", "TERM": "xterm-kitty"}, TerminalCapabilities{}},
+		{true, map[string]string{"TERM": "xterm-256color"}, TerminalCapabilities{TTY: true}},
+		{true, map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, TerminalCapabilities{TTY: true, TrueColor: true}},
+		{true, map[string]string{"TERM": "xterm-kitty"}, TerminalCapabilities{TTY: true, TrueColor: true, Kitty: true}},
+		{true, map[string]string{"TERM": "foot"}, TerminalCapabilities{TTY: true, TrueColor: true, Sixel: true}},
+		{true, map[string]string{"TERM_PROGRAM": "WezTerm"}, TerminalCapabilities{TTY: true, TrueColor: true, Sixel: true, Kitty: true}},
+	}
+	for _, test := range tests {
+		got := detectTerminal(test.tty, func(key string) string { return test.env[key] })
+		if got != test.want {
+			t.Errorf("tty %v, environment %v: got %+v, want %+v", test.tty, test.env, got, test.want)
+		}
+	}
+}
+
+func TestANSIString(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ForegroundColor = color.NRGBA{0x20, 0x40, 0x80, 0xff}
+	q.BackgroundColor = color.NRGBA{0xff, 0xf0, 0xe0, 0xff}
+	bitmap := q.Bitmap()
+	// Decode the modules from the colours of each ▀
+	codes := regexp.MustCompile("^\x1b\\[(38|48);2;(\\d+);(\\d+);(\\d+)m")
+	fg, bg := "32;64;128", "255;240;224"
+	var decoded [][]bool
+	for _, line := range strings.Split(strings.TrimSuffix(q.ANSIString(), "\n"), "\n") {
+		if !strings.HasSuffix(line, "\x1b[0m") {
+			t.Fatalf("line %q does not reset the colours", line)
+		}
+		line = strings.TrimSuffix(line, "\x1b[0m")
+		var upper, lower []bool
+		var colors [2]string
+		for line != "" {
+			if m := codes.FindStringSubmatch(line); m != nil {
+				if m[1] == "48" {
+					colors[1] = m[2] + ";" + m[3] + ";" + m[4]
+				} else {
+					colors[0] = m[2] + ";" + m[3] + ";" + m[4]
+				}
+				line = line[len(m[0]):]
+				continue
+			}
+			if !strings.HasPrefix(line, "▀") {
+				t.Fatalf("unexpected text %q", line)
+			}
+			line = line[len("▀"):]
+			for _, c := range colors {
+				if c != fg && c != bg {
+					t.Fatalf("got colour %q, want %q or %q", c, fg, bg)
+				}
+			}
+			upper = append(upper, colors[0] == fg)
+			lower = append(lower, colors[1] == fg)
+		}
+		decoded = append(decoded, upper, lower)
+	}
+	if len(bitmap)%2 == 1 {
+		// The last row is drawn over the background
+		decoded = decoded[:len(decoded)-1]
+	}
+	if fmt.Sprint(decoded) != fmt.Sprint(bitmap) {
+		t.Errorf("decoded modules differ from Bitmap():\n%v\n%v", decoded, bitmap)
+	}
+}
+
+func TestANSINilColors(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := q.ANSIString()
+	q.ForegroundColor, q.BackgroundColor = nil, nil
+	if got := q.ANSIString(); got != want {
+		t.Errorf("ANSIString() with nil colours differs from black on white:\n%q\n%q", got, want)
+	}
+	var b bytes.Buffer
+	if err := q.WriteANSI(&b); err == nil || b.Len() != 0 {
+		t.Errorf("WriteANSI() with nil colours wrote %d bytes, error %v", b.Len(), err)
+	}
+	q.ForegroundColor, q.BackgroundColor = color.Black, color.White
+	if err := q.WriteANSI(&b); err != nil || b.String() != want {
+		t.Errorf("WriteANSI() = %v, wrote %q, want %q", err, b.String(), want)
+	}
+}
+
+// Decodes a sixel image as written by WriteSixel() into rows of palette indexes
+func decodeSixel(t *testing.T, data string) (rows [][]int, palette map[int]string) {
+	header := regexp.MustCompile("^\x1bPq\"1;1;(\\d+);(\\d+)")
+	m := header.FindStringSubmatch(data)
+	if m == nil || !strings.HasSuffix(data, "\x1b\\") {
+		t.Fatalf("invalid sixel image %q", data)
+	}
+	width, _ := strconv.Atoi(m[1])
+	height, _ := strconv.Atoi(m[2])
+	rows = make([][]int, height)
+	for y := range rows {
+		rows[y] = make([]int, width)
+		for x := range rows[y] {
+			rows[y][x] = -1
+		}
+	}
+	data = strings.TrimSuffix(data[len(m[0]):], "\x1b\\")
+	palette = make(map[int]string)
+	register := regexp.MustCompile(`^#(\d+);2;(\d+;\d+;\d+)`)
+	selectColor := regexp.MustCompile(`^#(\d+)`)
+	repeat := regexp.MustCompile(`^!(\d+)`)
+	band, x, c := 0, 0, 0
+	for data != "" {
+		if m := register.FindStringSubmatch(data); m != nil {
+			i, _ := strconv.Atoi(m[1])
+			palette[i] = m[2]
+			data = data[len(m[0]):]
+			continue
+		}
+		if m := selectColor.FindStringSubmatch(data); m != nil {
+			c, _ = strconv.Atoi(m[1])
+			data = data[len(m[0]):]
+			continue
+		}
+		n := 1
+		if m := repeat.FindStringSubmatch(data); m != nil {
+			n, _ = strconv.Atoi(m[1])
+			data = data[len(m[0]):]
+		}
+		switch ch := data[0]; {
+		case ch == '$':
+			x = 0
+		case ch == '-':
+			band, x = band+1, 0
+		case ch >= '?' && ch <= '~':
+			for ; n > 0; n-- {
+				for i := 0; i < 6; i++ {
+					if (ch-'?')&(1<<uint(i)) != 0 {
+						rows[band*6+i][x] = c
+					}
+				}
+				x++
+			}
+		default:
+			t.Fatalf("unexpected sixel data %q", data)
+		}
+		data = data[1:]
+	}
+	return rows, palette
+}
+
+func TestWriteSixel(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Not a multiple of the band height
+	const size = 100
+	var b bytes.Buffer
+	if err := q.WriteSixel(&b, size); err != nil {
+		t.Fatal(err)
+	}
+	rows, palette := decodeSixel(t, b.String())
+	if palette[0] != "100;100;100" || palette[1] != "0;0;0" {
+		t.Errorf("got palette %v, want white and black", palette)
+	}
+	img := q.Image(size)
+	if len(rows) != size || len(rows[0]) != size {
+		t.Fatalf("got a %dx%d image, want %dx%d", len(rows[0]), len(rows), size, size)
+	}
+	for y := range rows {
+		for x, c := range rows[y] {
+			want := 0
+			if sameColor(img.At(x, y), color.Black) {
+				want = 1
+			}
+			if c != want {
+				t.Fatalf("pixel (%d, %d): got colour %d, want %d", x, y, c, want)
+			}
+		}
+	}
+
+	q.Style = &Style{Finder: PatternStyle{OuterColor: color.NRGBA{0xff, 0, 0, 0xff}}}
+	b.Reset()
+	if err := q.WriteSixel(&b, -2); err != nil {
+		t.Fatal(err)
+	}
+	if _, palette := decodeSixel(t, b.String()); len(palette) != 3 {
+		t.Errorf("got palette %v, want the 3 colours of the styled image", palette)
+	}
+}
+
+func TestWriteKitty(t *testing.T) {
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A gradient gives an image large enough to need several chunks
+	q.Fill = &LinearGradient{X1: 1, Y1: 1, Stops: []GradientStop{{0, color.Black}, {1, color.NRGBA{0, 0, 0x80, 0xff}}}}
+	var b bytes.Buffer
+	if err := q.WriteKitty(&b, 2000); err != nil {
+		t.Fatal(err)
+	}
+	chunks := regexp.MustCompile("\x1b_G([^;]*);([^\x1b]*)\x1b\\\\").FindAllStringSubmatch(b.String(), -1)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	var payload string
+	for i, c := range chunks {
+		want := "m=1"
+		if i == 0 {
+			want = "a=T,f=100," + want
+		}
+		if i == len(chunks)-1 {
+			want = strings.TrimSuffix(want, "1") + "0"
+		}
+		if c[1] != want {
+			t.Errorf("chunk %d: got control data %q, want %q", i, c[1], want)
+		}
+		if len(c[2]) > kittyChunkSize {
+			t.Errorf("chunk %d: got %d bytes, want at most %d", i, len(c[2]), kittyChunkSize)
+		}
+		payload += c[2]
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 2000 {
+		t.Errorf("got a %v image, want 2000 pixels wide", img.Bounds())
+	}
+}
@@ -0,0 +1,124 @@
+package getqr
+
+import "strings"
+
+// Unicode quadrant block characters, indexed by their lit quadrants: 1 upper left, 2 upper right, 4 lower left, 8 lower right
+var quadrantBlocks = [16]string{" ", "▘", "▝", "▀", "▖", "▌", "▞", "▛", "▗", "▚", "▐", "▜", "▄", "▙", "▟", "█"}
+
+// Bits of the dots of a Braille pattern character, indexed by row and column, added to U+2800
+var brailleDots = [4][2]rune{{0x01, 0x08}, {0x02, 0x10}, {0x04, 0x20}, {0x40, 0x80}}
+
+// Returns bits drawn with two full block characters for each light module, or each dark module if inverseColor is set, see ToString()
+func blockText(bits [][]bool, inverseColor bool) string {
+	var buf strings.Builder
+	for y := range bits {
+		for x := range bits[y] {
+			if bits[y][x] != inverseColor {
+				buf.WriteString("  ")
+			} else {
+				buf.WriteString("██")
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// Returns bits drawn with half block characters, two modules per character, see ToSmallString()
+func smallBlockText(bits [][]bool, inverseColor bool) string {
+	var buf strings.Builder
+	// If there is an odd number of rows, the last one needs special treatment
+	for y := 0; y < len(bits)-1; y += 2 {
+		for x := range bits[y] {
+			if bits[y][x] == bits[y+1][x] {
+				if bits[y][x] != inverseColor {
+					buf.WriteString(" ")
+				} else {
+					buf.WriteString("█")
+				}
+			} else {
+				if bits[y][x] != inverseColor {
+					buf.WriteString("▄")
+				} else {
+					buf.WriteString("▀")
+				}
+			}
+		}
+		buf.WriteString("\n")
+	}
+	// Special treatment for the last row if odd
+	if len(bits)%2 == 1 {
+		y := len(bits) - 1
+		for x := range bits[y] {
+			if bits[y][x] != inverseColor {
+				buf.WriteString(" ")
+			} else {
+				buf.WriteString("▀")
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// Produces a multi-line string that forms a QR-code image with Unicode quadrant block characters, 2x2 modules per character
+// The string is half the height of ToSmallString() and a quarter the width of ToString()
+// As for ToString(), the blocks are the light modules unless inverseColor is set
+func (q *QRCode) ToQuarterString(inverseColor bool) string {
+	return quarterText(q.Bitmap(), inverseColor)
+}
+
+// Returns bits drawn with quadrant block characters, see ToQuarterString()
+func quarterText(bits [][]bool, inverseColor bool) string {
+	return cellString(bits, inverseColor, 2, 2, func(lit func(x, y int) bool) string {
+		i := 0
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 2; x++ {
+				if lit(x, y) {
+					i |= 1 << uint(2*y+x)
+				}
+			}
+		}
+		return quadrantBlocks[i]
+	})
+}
+
+// Produces a multi-line string that forms a QR-code image with Braille pattern characters, 2x4 modules per character
+// This is the most compact text rendering, but scans less reliably, as the dots do not fill their modules
+// As for ToString(), the dots are the light modules unless inverseColor is set
+func (q *QRCode) ToBrailleString(inverseColor bool) string {
+	return brailleText(q.Bitmap(), inverseColor)
+}
+
+// Returns bits drawn with Braille pattern characters, see ToBrailleString()
+func brailleText(bits [][]bool, inverseColor bool) string {
+	return cellString(bits, inverseColor, 2, 4, func(lit func(x, y int) bool) string {
+		r := rune(0x2800)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 2; x++ {
+				if lit(x, y) {
+					r |= brailleDots[y][x]
+				}
+			}
+		}
+		return string(r)
+	})
+}
+
+// Returns bits drawn with a character for each w x h cell of modules, given by glyph from the modules lit in the cell
+// A module is lit if it is light, or dark if inverseColor is set. Cells past the edges of bits are unlit there
+func cellString(bits [][]bool, inverseColor bool, w, h int, glyph func(lit func(x, y int) bool) string) string {
+	var buf strings.Builder
+	for y := 0; y < len(bits); y += h {
+		for x := 0; x < len(bits[y]); x += w {
+			buf.WriteString(glyph(func(dx, dy int) bool {
+				if y+dy >= len(bits) || x+dx >= len(bits[y+dy]) {
+					return false
+				}
+				return bits[y+dy][x+dx] == inverseColor
+			}))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,84 @@
+package getqr
+
+import (
+	"strings"
+	"testing"
+)
+
+// Decodes a string of w x h module cells into a bitmap of size x size, using lit to give the lit modules of each character
+func decodeCells(t *testing.T, s string, size, w, h int, lit func(c rune) (func(x, y int) bool, bool)) [][]bool {
+	bitmap := make([][]bool, size)
+	for i := range bitmap {
+		bitmap[i] = make([]bool, size)
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if want := (size + h - 1) / h; len(lines) != want {
+		t.Fatalf("got %d lines, want %d", len(lines), want)
+	}
+	for cy, line := range lines {
+		cells := []rune(line)
+		if want := (size + w - 1) / w; len(cells) != want {
+			t.Fatalf("line %d: got %d characters, want %d", cy, len(cells), want)
+		}
+		for cx, c := range cells {
+			isLit, ok := lit(c)
+			if !ok {
+				t.Fatalf("unexpected character %q", c)
+			}
+			for dy := 0; dy < h; dy++ {
+				for dx := 0; dx < w; dx++ {
+					x, y := cx*w+dx, cy*h+dy
+					if x < size && y < size {
+						bitmap[y][x] = isLit(dx, dy)
+					} else if isLit(dx, dy) {
+						t.Errorf("module (%d, %d) past the edge is lit", x, y)
+					}
+				}
+			}
+		}
+	}
+	return bitmap
+}
+
+func TestCompactStrings(t *testing.T) {
+	quadrants := make(map[rune]int)
+	for i, b := range quadrantBlocks {
+		quadrants[[]rune(b)[0]] = i
+	}
+	decoders := []struct {
+		name   string
+		render func(q *QRCode, inverseColor bool) string
+		w, h   int
+		lit    func(c rune) (func(x, y int) bool, bool)
+	}{
+		{"ToQuarterString", (*QRCode).ToQuarterString, 2, 2, func(c rune) (func(x, y int) bool, bool) {
+			i, ok := quadrants[c]
+			return func(x, y int) bool { return i&(1<<uint(2*y+x)) != 0 }, ok
+		}},
+		{"ToBrailleString", (*QRCode).ToBrailleString, 2, 4, func(c rune) (func(x, y int) bool, bool) {
+			return func(x, y int) bool { return (c-0x2800)&brailleDots[y][x] != 0 }, c >= 0x2800 && c <= 0x28ff
+		}},
+	}
+	q, err := New("https://github.com/pchchv/getqr", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Quiet zones giving widths of each remainder
+	for _, quietZone := range []int{0, 1, 4} {
+		q.QuietZone = quietZone
+		bitmap := q.Bitmap()
+		for _, d := range decoders {
+			for _, inverseColor := range []bool{false, true} {
+				decoded := decodeCells(t, d.render(q, inverseColor), len(bitmap), d.w, d.h, d.lit)
+				for y := range bitmap {
+					for x := range bitmap[y] {
+						if want := bitmap[y][x] == inverseColor; decoded[y][x] != want {
+							t.Fatalf("%s(%v) with quiet zone %d: module (%d, %d) got lit %v, want %v",
+								d.name, inverseColor, quietZone, x, y, decoded[y][x], want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -2881,8 +2881,8 @@ func (v qrCodeVersion) numBlocks() int {
 	return numBlocks
 }
 
-// Returns the number of pixels of border space on each side of the QR Code
-// The quiet space assists with decoding
+// Returns the standard number of modules of border space on each side of the QR Code
+// The quiet space assists with decoding. This is the default for QRCode.QuietZone
 func (v qrCodeVersion) quietZoneSize() int {
 	return 4
 }
@@ -2895,7 +2895,8 @@ func (v qrCodeVersion) symbolSize() int {
 }
 
 // Returns the 15-bit Format Information value for a QR code
-func (v qrCodeVersion) formatInfo(maskPattern int) *bitset.Bitset {
+// Bit 14 is the first bit placed in the symbol
+func (v qrCodeVersion) formatInfo(maskPattern int) uint32 {
 	formatID := 0
 	switch v.level {
 	case Low:
@@ -2913,9 +2914,7 @@ func (v qrCodeVersion) formatInfo(maskPattern int) *bitset.Bitset {
 		log.Panicf("Invalid maskPattern %d", maskPattern)
 	}
 	formatID |= maskPattern & 0x7
-	result := bitset.New()
-	result.AppendUint32(formatBitSequence[formatID].regular, formatInfoLengthBits)
-	return result
+	return formatBitSequence[formatID].regular
 }
 
 // Returns the 18-bit Version Information value for a QR Code
@@ -2933,9 +2932,9 @@ func (v qrCodeVersion) versionInfo() *bitset.Bitset {
 // Returns the QR code version by version number and recovery level
 // Returns nil if the requested combination is not defined
 func getQRCodeVersion(level RecoveryLevel, version int) *qrCodeVersion {
-	for _, v := range versions {
-		if v.level == level && v.version == version {
-			return &v
+	for i := range versions {
+		if v := &versions[i]; v.level == level && v.version == version {
+			return v
 		}
 	}
 	return nil
@@ -2946,7 +2945,8 @@ func getQRCodeVersion(level RecoveryLevel, version int) *qrCodeVersion {
 // The chosen QR Code version is returned
 func chooseQRCodeVersion(level RecoveryLevel, encoder *dataEncoder, numDataBits int) *qrCodeVersion {
 	var chosenVersion *qrCodeVersion
-	for _, v := range versions {
+	for i := range versions {
+		v := &versions[i]
 		if v.level != level {
 			continue
 		} else if v.version < encoder.minVersion {
@@ -2956,7 +2956,7 @@ func chooseQRCodeVersion(level RecoveryLevel, encoder *dataEncoder, numDataBits
 		}
 		numFreeBits := v.numDataBits() - numDataBits
 		if numFreeBits >= 0 {
-			chosenVersion = &v
+			chosenVersion = v
 			break
 		}
 	}